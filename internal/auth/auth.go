@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"net/http"
@@ -10,11 +11,16 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// PasswordCost is the bcrypt cost of new password hashes. Hashes with a
+// lower cost are upgraded the next time their owner logs in.
+const PasswordCost = 10
+
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
 	if err != nil {
 		return "", err
 	}
@@ -26,36 +32,172 @@ func CheckPassword(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+// NeedsRehash reports whether hash was made with a lower cost than
+// PasswordCost and should be replaced once the password is known.
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < PasswordCost
+}
+
+// Claims are the claims of a Chirpy access token.
+type Claims struct {
+	Roles       []string `json:"roles,omitempty"`
+	IsChirpyRed bool     `json:"red,omitempty"`
+	// SessionID is the refresh token family the token was issued from.
+	SessionID string `json:"sid,omitempty"`
+	// Purpose is set on tokens that are not access tokens, such as MFA
+	// challenges, so they cannot be used in their place.
+	Purpose string `json:"purpose,omitempty"`
+	jwt.RegisteredClaims
+}
+
+const (
+	purposeMFA       = "mfa"
+	purposeOIDCState = "oidc_state"
+)
+
+// UserID parses the subject of the token.
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// MakeJWT issues an access token for userID carrying its roles.
+func MakeJWT(userID uuid.UUID, keys *Keyring, expiresIn time.Duration, roles ...string) (string, error) {
+	return makeJWT(Claims{Roles: roles}, userID, keys, expiresIn)
+}
+
+// MakeUserJWT issues an access token for a session of user, carrying the
+// user's roles and Chirpy Red status. Changes to either show up once the
+// token is refreshed.
+func MakeUserJWT(user *models.User, sessionID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	claims := Claims{Roles: user.Roles, IsChirpyRed: user.IsChirpyRed, SessionID: sessionID.String()}
+	return makeJWT(claims, user.ID, keys, expiresIn)
+}
+
+// MakeMFAToken issues the token a login with 2FA enabled returns in place
+// of an access token. It proves the password was checked.
+func MakeMFAToken(userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	return makeJWT(Claims{Purpose: purposeMFA}, userID, keys, expiresIn)
+}
+
+func makeJWT(claims Claims, userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	now := time.Now().UTC()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
 		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn * time.Second)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 		Subject:   userID.String(),
-	})
+	}
 
-	return token.SignedString([]byte(tokenSecret))
+	return keys.sign(claims)
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	parsedToken, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
-	})
+// ValidateJWT validates an access token and returns the user it was issued
+// for. Tokens on denylist are rejected, a nil denylist skips that check.
+func ValidateJWT(tokenString string, keys *Keyring, denylist *Denylist) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, keys, denylist)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return claims.UserID()
+}
 
+// ParseJWT validates an access token like ValidateJWT and returns its
+// claims.
+func ParseJWT(tokenString string, keys *Keyring, denylist *Denylist) (*Claims, error) {
+	claims, err := parseClaims(tokenString, keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("not an access token")
+	}
+	if denylist != nil && denylist.Denies(claims) {
+		return nil, errors.New("token has been revoked")
+	}
+
+	return claims, nil
+}
+
+// ParseMFAToken validates a token from MakeMFAToken and returns the user it
+// was issued for.
+func ParseMFAToken(tokenString string, keys *Keyring) (uuid.UUID, error) {
+	claims, err := parseClaims(tokenString, keys)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
+	if claims.Purpose != purposeMFA {
+		return uuid.UUID{}, errors.New("not an MFA token")
+	}
+
+	return claims.UserID()
+}
+
+func parseClaims(tokenString string, keys *Keyring) (*Claims, error) {
+	claims := &Claims{}
+	parsedToken, err := keys.parse(tokenString, claims)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if !parsedToken.Valid {
-		return uuid.UUID{}, errors.New("invalid token")
+		return nil, errors.New("invalid token")
+	}
+
+	if _, err := claims.UserID(); err != nil {
+		return nil, errors.New("invalid token subject")
+	}
+
+	return claims, nil
+}
+
+// OIDCState is what a login through an OpenID Connect provider has to
+// remember between sending the user to the provider and the callback.
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+type oidcStateClaims struct {
+	OIDCState
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// MakeOIDCStateToken signs state so it can be kept in a cookie instead of
+// on the server.
+func MakeOIDCStateToken(state OIDCState, keys *Keyring, expiresIn time.Duration) (string, error) {
+	now := time.Now().UTC()
+	claims := oidcStateClaims{
+		OIDCState: state,
+		Purpose:   purposeOIDCState,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+		},
 	}
 
-	userID, err := parsedToken.Claims.GetSubject()
+	return keys.sign(claims)
+}
+
+// ParseOIDCStateToken validates a token from MakeOIDCStateToken.
+func ParseOIDCStateToken(tokenString string, keys *Keyring) (*OIDCState, error) {
+	claims := &oidcStateClaims{}
+	parsedToken, err := keys.parse(tokenString, claims, jwt.WithExpirationRequired())
 	if err != nil {
-		return uuid.UUID{}, err
+		return nil, err
+	}
+	if !parsedToken.Valid || claims.Purpose != purposeOIDCState {
+		return nil, errors.New("not an OIDC state token")
 	}
 
-	return uuid.MustParse(userID), nil
+	return &claims.OIDCState, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -78,6 +220,11 @@ func GetAPIKey(headers http.Header) (string, error) {
 }
 
 func MakeRefreshToken() (string, error) {
+	return MakeRandomToken()
+}
+
+// MakeRandomToken returns 32 random bytes, hex encoded.
+func MakeRandomToken() (string, error) {
 	data := make([]byte, 32)
 	_, err := rand.Read(data)
 	if err != nil {
@@ -86,3 +233,10 @@ func MakeRefreshToken() (string, error) {
 
 	return hex.EncodeToString(data), nil
 }
+
+// HashToken returns the SHA-256 of token, hex encoded, for storing tokens
+// that are looked up but never shown again.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,14 +1,21 @@
 package auth
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// testKeys signs with a legacy HS256 secret, like a server without
+// configured signing keys.
+var testKeys = NewKeyring("TestTokenSecret")
+
 func TestGenerateJWT(t *testing.T) {
-	_, err := MakeJWT(uuid.New(), "TestTokenSecret", 10*time.Minute)
+	_, err := MakeJWT(uuid.New(), testKeys, 10*time.Minute)
 	if err != nil {
 		t.Errorf("Expected no error but got error: %v", err)
 	}
@@ -16,8 +23,8 @@ func TestGenerateJWT(t *testing.T) {
 
 func TestValidateJWT(t *testing.T) {
 	ID := uuid.New()
-	token, _ := MakeJWT(ID, "TestTokenSecret", 10*time.Minute)
-	userID, err := ValidateJWT(token, "TestTokenSecret")
+	token, _ := MakeJWT(ID, testKeys, 10*time.Minute)
+	userID, err := ValidateJWT(token, testKeys, nil)
 	if err != nil {
 		t.Errorf("Expected no error but got error: %v", err)
 	}
@@ -26,3 +33,125 @@ func TestValidateJWT(t *testing.T) {
 		t.Errorf("ID: %s and userID: %s not equal", ID.String(), userID.String())
 	}
 }
+
+func TestJWTRoles(t *testing.T) {
+	token, err := MakeJWT(uuid.New(), testKeys, time.Minute, "user", "moderator")
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+
+	claims, err := ParseJWT(token, testKeys, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if !HasPermission(claims.Roles, PermModerate) {
+		t.Errorf("Expected roles %v to grant %s", claims.Roles, PermModerate)
+	}
+	if HasPermission(claims.Roles, PermManageRoles) {
+		t.Errorf("Expected roles %v not to grant %s", claims.Roles, PermManageRoles)
+	}
+}
+
+func TestValidateJWTExpired(t *testing.T) {
+	token, _ := MakeJWT(uuid.New(), testKeys, -time.Minute)
+	if _, err := ValidateJWT(token, testKeys, nil); err == nil {
+		t.Error("Expected expired token to be rejected")
+	}
+}
+
+func TestValidateJWTWrongSecret(t *testing.T) {
+	token, _ := MakeJWT(uuid.New(), testKeys, time.Minute)
+	if _, err := ValidateJWT(token, NewKeyring("OtherSecret"), nil); err == nil {
+		t.Error("Expected token signed with another secret to be rejected")
+	}
+}
+
+func TestPrincipalFromUserJWT(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Roles: []string{"user"}, IsChirpyRed: true}
+	sessionID := uuid.New()
+	token, _ := MakeUserJWT(user, sessionID, testKeys, time.Minute)
+
+	claims, err := ParseJWT(token, testKeys, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	principal, err := NewPrincipal(claims)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+
+	if principal.UserID != user.ID || !principal.IsChirpyRed || principal.TokenID == "" || principal.SessionID != sessionID {
+		t.Errorf("Unexpected principal: %+v", principal)
+	}
+
+	ctx := WithPrincipal(context.Background(), principal)
+	if PrincipalFrom(ctx) != principal {
+		t.Error("Expected principal to round trip through the context")
+	}
+	if PrincipalFrom(context.Background()) != nil {
+		t.Error("Expected no principal on an anonymous context")
+	}
+}
+
+func TestMFATokenIsNotAnAccessToken(t *testing.T) {
+	ID := uuid.New()
+	token, _ := MakeMFAToken(ID, testKeys, time.Minute)
+
+	if _, err := ValidateJWT(token, testKeys, nil); err == nil {
+		t.Error("Expected MFA token to be rejected as an access token")
+	}
+	userID, err := ParseMFAToken(token, testKeys)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if userID != ID {
+		t.Errorf("ID: %s and userID: %s not equal", ID, userID)
+	}
+
+	accessToken, _ := MakeJWT(ID, testKeys, time.Minute)
+	if _, err := ParseMFAToken(accessToken, testKeys); err == nil {
+		t.Error("Expected access token to be rejected as an MFA token")
+	}
+}
+
+func TestOIDCStateToken(t *testing.T) {
+	state := OIDCState{Provider: "google", State: "s", Nonce: "n", CodeVerifier: "v"}
+	token, err := MakeOIDCStateToken(state, testKeys, time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+
+	parsed, err := ParseOIDCStateToken(token, testKeys)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if *parsed != state {
+		t.Errorf("Expected %+v but got %+v", state, *parsed)
+	}
+	if _, err := ValidateJWT(token, testKeys, nil); err == nil {
+		t.Error("Expected OIDC state token to be rejected as an access token")
+	}
+
+	accessToken, _ := MakeJWT(uuid.New(), testKeys, time.Minute)
+	if _, err := ParseOIDCStateToken(accessToken, testKeys); err == nil {
+		t.Error("Expected access token to be rejected as an OIDC state token")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := HashPassword("hunter22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if NeedsRehash(hash) {
+		t.Errorf("Expected hash with the current cost not to need a rehash")
+	}
+
+	cheap, err := bcrypt.GenerateFromPassword([]byte("hunter22"), PasswordCost-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !NeedsRehash(string(cheap)) {
+		t.Errorf("Expected hash with a lower cost to need a rehash")
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Denylist holds the ids of access tokens revoked before they expire. An id
+// is either a token's jti or the session id in its sid claim, which revokes
+// every token issued to that session. Entries are dropped once the tokens
+// they name have expired. It is safe for concurrent use.
+type Denylist struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+func NewDenylist() *Denylist {
+	return &Denylist{entries: make(map[string]time.Time)}
+}
+
+// Add denies id until expiresAt. Adding an id again keeps the later expiry.
+func (d *Denylist) Add(id string, expiresAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if current, ok := d.entries[id]; !ok || expiresAt.After(current) {
+		d.entries[id] = expiresAt
+	}
+}
+
+// Contains reports whether id is denied.
+func (d *Denylist) Contains(id string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	expiresAt, ok := d.entries[id]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// Denies reports whether the token with claims was revoked, by its own id
+// or its session's.
+func (d *Denylist) Denies(claims *Claims) bool {
+	if claims.ID != "" && d.Contains(claims.ID) {
+		return true
+	}
+	return claims.SessionID != "" && d.Contains(claims.SessionID)
+}
+
+// Prune drops the entries that expired before now.
+func (d *Denylist) Prune(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, expiresAt := range d.entries {
+		if !now.Before(expiresAt) {
+			delete(d.entries, id)
+		}
+	}
+}
+
+// Len returns the number of entries, including expired ones not pruned yet.
+func (d *Denylist) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.entries)
+}
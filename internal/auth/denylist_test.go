@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+func TestDenylistRejectsRevokedToken(t *testing.T) {
+	denylist := NewDenylist()
+	token, _ := MakeJWT(uuid.New(), testKeys, time.Minute)
+	other, _ := MakeJWT(uuid.New(), testKeys, time.Minute)
+
+	claims, err := ParseJWT(token, testKeys, denylist)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	denylist.Add(claims.ID, claims.ExpiresAt.Time)
+
+	if _, err := ValidateJWT(token, testKeys, denylist); err == nil {
+		t.Error("Expected revoked token to be rejected")
+	}
+	if _, err := ValidateJWT(other, testKeys, denylist); err != nil {
+		t.Errorf("Expected other token to stay valid but got error: %v", err)
+	}
+}
+
+func TestDenylistRevokesSession(t *testing.T) {
+	denylist := NewDenylist()
+	user := &models.User{ID: uuid.New()}
+	sessionID := uuid.New()
+	first, _ := MakeUserJWT(user, sessionID, testKeys, time.Minute)
+	second, _ := MakeUserJWT(user, sessionID, testKeys, time.Minute)
+	otherSession, _ := MakeUserJWT(user, uuid.New(), testKeys, time.Minute)
+
+	denylist.Add(sessionID.String(), time.Now().Add(time.Minute))
+
+	for _, token := range []string{first, second} {
+		if _, err := ValidateJWT(token, testKeys, denylist); err == nil {
+			t.Error("Expected token of revoked session to be rejected")
+		}
+	}
+	if _, err := ValidateJWT(otherSession, testKeys, denylist); err != nil {
+		t.Errorf("Expected token of other session to stay valid but got error: %v", err)
+	}
+}
+
+func TestDenylistExpiry(t *testing.T) {
+	denylist := NewDenylist()
+	denylist.Add("expired", time.Now().Add(-time.Second))
+	denylist.Add("live", time.Now().Add(time.Minute))
+	denylist.Add("live", time.Now().Add(-time.Minute))
+
+	if denylist.Contains("expired") {
+		t.Error("Expected expired entry to be ignored")
+	}
+	if !denylist.Contains("live") {
+		t.Error("Expected re-adding with an earlier expiry to keep the entry")
+	}
+
+	denylist.Prune(time.Now())
+	if denylist.Len() != 1 {
+		t.Errorf("Expected 1 entry after pruning but got %d", denylist.Len())
+	}
+}
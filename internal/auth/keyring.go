@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// Algorithms tokens can be signed with.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// rsaKeySize is the modulus size of generated RS256 keys.
+const rsaKeySize = 2048
+
+// keyReloadInterval limits how often an unknown key id makes the keyring
+// reload its keys.
+const keyReloadInterval = 10 * time.Second
+
+// Keyring holds the keys tokens are signed and verified with, selected by
+// the kid header. The newest key that is not retired signs new tokens, the
+// others only verify tokens issued before a rotation. It is safe for
+// concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	signing *keyEntry
+	keys    map[string]*keyEntry
+	// legacy is the secret of tokens issued before keys had ids. It also
+	// signs new tokens while no keys are loaded.
+	legacy []byte
+
+	source     func() ([]models.SigningKey, error)
+	reloadedAt time.Time
+}
+
+type keyEntry struct {
+	key     models.SigningKey
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+// NewKeyring returns a keyring that verifies tokens without kid against
+// legacySecret. An empty legacySecret disables that.
+func NewKeyring(legacySecret string) *Keyring {
+	keyring := &Keyring{keys: make(map[string]*keyEntry)}
+	if legacySecret != "" {
+		keyring.legacy = []byte(legacySecret)
+	}
+	return keyring
+}
+
+// GenerateSigningKey creates a key for algorithm with a random id.
+func GenerateSigningKey(algorithm string) (models.SigningKey, error) {
+	key := models.SigningKey{
+		ID:        uuid.NewString(),
+		Algorithm: algorithm,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	var err error
+	switch algorithm {
+	case AlgHS256:
+		key.Private = make([]byte, 32)
+		_, err = rand.Read(key.Private)
+	case AlgRS256:
+		var private *rsa.PrivateKey
+		if private, err = rsa.GenerateKey(rand.Reader, rsaKeySize); err == nil {
+			key.Private, err = x509.MarshalPKCS8PrivateKey(private)
+		}
+	case AlgEdDSA:
+		var private ed25519.PrivateKey
+		if _, private, err = ed25519.GenerateKey(rand.Reader); err == nil {
+			key.Private, err = x509.MarshalPKCS8PrivateKey(private)
+		}
+	default:
+		return models.SigningKey{}, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	return key, nil
+}
+
+// SetKeys replaces the keys of the keyring.
+func (k *Keyring) SetKeys(keys []models.SigningKey) error {
+	entries := make(map[string]*keyEntry, len(keys))
+	var signing *keyEntry
+	for _, key := range keys {
+		entry, err := newKeyEntry(key)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", key.ID, err)
+		}
+		entries[key.ID] = entry
+		if key.RetiredAt == nil && (signing == nil || key.CreatedAt.After(signing.key.CreatedAt)) {
+			signing = entry
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = entries
+	k.signing = signing
+	return nil
+}
+
+// SetSource lets the keyring reload its keys when it sees a token signed
+// with a key it does not know, such as one rotated in by another instance.
+func (k *Keyring) SetSource(source func() ([]models.SigningKey, error)) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.source = source
+}
+
+// SigningKey returns the key new tokens are signed with, if any.
+func (k *Keyring) SigningKey() (models.SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.signing == nil {
+		return models.SigningKey{}, false
+	}
+	return k.signing.key, true
+}
+
+func newKeyEntry(key models.SigningKey) (*keyEntry, error) {
+	switch key.Algorithm {
+	case AlgHS256:
+		if len(key.Private) == 0 {
+			return nil, errors.New("empty secret")
+		}
+		return &keyEntry{key: key, method: jwt.SigningMethodHS256, private: key.Private, public: key.Private}, nil
+	case AlgRS256:
+		parsed, err := x509.ParsePKCS8PrivateKey(key.Private)
+		if err != nil {
+			return nil, err
+		}
+		private, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("not an RSA key")
+		}
+		return &keyEntry{key: key, method: jwt.SigningMethodRS256, private: private, public: &private.PublicKey}, nil
+	case AlgEdDSA:
+		parsed, err := x509.ParsePKCS8PrivateKey(key.Private)
+		if err != nil {
+			return nil, err
+		}
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("not an Ed25519 key")
+		}
+		return &keyEntry{key: key, method: jwt.SigningMethodEdDSA, private: private, public: private.Public()}, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %q", key.Algorithm)
+}
+
+// sign signs claims with the signing key, or the legacy secret when there is
+// none.
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	signing, legacy := k.signing, k.legacy
+	k.mu.RUnlock()
+
+	if signing == nil {
+		if legacy == nil {
+			return "", errors.New("no signing key")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(legacy)
+	}
+
+	token := jwt.NewWithClaims(signing.method, claims)
+	token.Header["kid"] = signing.key.ID
+	return token.SignedString(signing.private)
+}
+
+// parse validates tokenString into claims with the key its kid names.
+func (k *Keyring) parse(tokenString string, claims jwt.Claims, options ...jwt.ParserOption) (*jwt.Token, error) {
+	options = append(options, jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}))
+	return jwt.ParseWithClaims(tokenString, claims, k.keyfunc, options...)
+}
+
+// keyfunc picks the verification key for t. The algorithm has to be the
+// one the key was made for, so a public key can never be used as an HMAC
+// secret.
+func (k *Keyring) keyfunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		k.mu.RLock()
+		legacy := k.legacy
+		k.mu.RUnlock()
+		if legacy == nil || t.Method.Alg() != AlgHS256 {
+			return nil, errors.New("token has no key id")
+		}
+		return legacy, nil
+	}
+
+	entry, ok := k.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if t.Method.Alg() != entry.method.Alg() {
+		return nil, fmt.Errorf("key %q is not a %s key", kid, t.Method.Alg())
+	}
+	return entry.public, nil
+}
+
+func (k *Keyring) lookup(kid string) (*keyEntry, bool) {
+	k.mu.RLock()
+	entry, ok := k.keys[kid]
+	source, reloadedAt := k.source, k.reloadedAt
+	k.mu.RUnlock()
+	if ok || source == nil || time.Since(reloadedAt) < keyReloadInterval {
+		return entry, ok
+	}
+
+	k.mu.Lock()
+	k.reloadedAt = time.Now()
+	k.mu.Unlock()
+	keys, err := source()
+	if err != nil || k.SetKeys(keys) != nil {
+		return nil, false
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	entry, ok = k.keys[kid]
+	return entry, ok
+}
+
+// JWK is a public key in the JSON Web Key format of RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys tokens can be verified with. HMAC keys are
+// secret and left out, so services verifying Chirpy tokens need the
+// keyring to use an asymmetric algorithm.
+func (k *Keyring) JWKS() JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	entries := make([]*keyEntry, 0, len(k.keys))
+	for _, entry := range k.keys {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b *keyEntry) int {
+		return b.key.CreatedAt.Compare(a.key.CreatedAt)
+	})
+
+	set := JWKS{Keys: []JWK{}}
+	for _, entry := range entries {
+		jwk := JWK{Kid: entry.key.ID, Use: "sig", Alg: entry.method.Alg()}
+		switch public := entry.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
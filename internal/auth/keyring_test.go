@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+func newTestKeyring(t *testing.T, algorithm string) (*Keyring, models.SigningKey) {
+	t.Helper()
+	key, err := GenerateSigningKey(algorithm)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	keys := NewKeyring("")
+	if err := keys.SetKeys([]models.SigningKey{key}); err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	return keys, key
+}
+
+func TestKeyringAlgorithms(t *testing.T) {
+	for _, algorithm := range []string{AlgHS256, AlgRS256, AlgEdDSA} {
+		keys, key := newTestKeyring(t, algorithm)
+		ID := uuid.New()
+
+		token, err := MakeJWT(ID, keys, time.Minute)
+		if err != nil {
+			t.Fatalf("%s: expected no error but got error: %v", algorithm, err)
+		}
+		parsed, _, _ := jwt.NewParser().ParseUnverified(token, &Claims{})
+		if parsed.Header["kid"] != key.ID || parsed.Method.Alg() != algorithm {
+			t.Errorf("%s: unexpected header %v", algorithm, parsed.Header)
+		}
+
+		userID, err := ValidateJWT(token, keys, nil)
+		if err != nil {
+			t.Fatalf("%s: expected no error but got error: %v", algorithm, err)
+		}
+		if userID != ID {
+			t.Errorf("%s: ID: %s and userID: %s not equal", algorithm, ID, userID)
+		}
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	keys, first := newTestKeyring(t, AlgEdDSA)
+	oldToken, _ := MakeJWT(uuid.New(), keys, time.Minute)
+
+	second, _ := GenerateSigningKey(AlgRS256)
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	retiredAt := second.CreatedAt
+	first.RetiredAt = &retiredAt
+	keys.SetKeys([]models.SigningKey{first, second})
+
+	if signing, _ := keys.SigningKey(); signing.ID != second.ID {
+		t.Errorf("Expected the new key to sign but got %s", signing.ID)
+	}
+	if _, err := ValidateJWT(oldToken, keys, nil); err != nil {
+		t.Errorf("Expected token of the retired key to stay valid but got error: %v", err)
+	}
+
+	keys.SetKeys([]models.SigningKey{second})
+	if _, err := ValidateJWT(oldToken, keys, nil); err == nil {
+		t.Error("Expected token of a removed key to be rejected")
+	}
+}
+
+func TestKeyringReloadsUnknownKeys(t *testing.T) {
+	keys, first := newTestKeyring(t, AlgEdDSA)
+	other, _ := newTestKeyring(t, AlgEdDSA)
+	second, _ := other.SigningKey()
+	token, _ := MakeJWT(uuid.New(), other, time.Minute)
+
+	keys.SetSource(func() ([]models.SigningKey, error) {
+		return []models.SigningKey{first, second}, nil
+	})
+	if _, err := ValidateJWT(token, keys, nil); err != nil {
+		t.Errorf("Expected key rotated in elsewhere to be loaded but got error: %v", err)
+	}
+}
+
+func TestKeyringRejectsAlgorithmConfusion(t *testing.T) {
+	keys, key := newTestKeyring(t, AlgRS256)
+	public := keys.keys[key.ID].public.(*rsa.PublicKey)
+
+	// An attacker who knows the public key signs with it as an HMAC secret.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	forged.Header["kid"] = key.ID
+	token, _ := forged.SignedString(public.N.Bytes())
+	if _, err := ValidateJWT(token, keys, nil); err == nil {
+		t.Error("Expected HS256 token under an RS256 key id to be rejected")
+	}
+
+	legacy, _ := MakeJWT(uuid.New(), NewKeyring("secret"), time.Minute)
+	if _, err := ValidateJWT(legacy, keys, nil); err == nil {
+		t.Error("Expected token without key id to be rejected without a legacy secret")
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	rsaKeys, rsaKey := newTestKeyring(t, AlgRS256)
+	edKeys, edKey := newTestKeyring(t, AlgEdDSA)
+	hmacKeys, _ := newTestKeyring(t, AlgHS256)
+
+	if set := hmacKeys.JWKS(); len(set.Keys) != 0 {
+		t.Errorf("Expected HMAC keys to stay private but got %+v", set.Keys)
+	}
+
+	jwk := rsaKeys.JWKS().Keys[0]
+	n, _ := base64.RawURLEncoding.DecodeString(jwk.N)
+	e, _ := base64.RawURLEncoding.DecodeString(jwk.E)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	token, _ := MakeJWT(uuid.New(), rsaKeys, time.Minute)
+	_, err := jwt.Parse(token, func(*jwt.Token) (any, error) { return public, nil })
+	if jwk.Kid != rsaKey.ID || jwk.Kty != "RSA" || err != nil {
+		t.Errorf("Expected published RSA key to verify tokens, got %+v: %v", jwk, err)
+	}
+
+	jwk = edKeys.JWKS().Keys[0]
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	token, _ = MakeJWT(uuid.New(), edKeys, time.Minute)
+	_, err = jwt.Parse(token, func(*jwt.Token) (any, error) { return ed25519.PublicKey(x), nil })
+	if jwk.Kid != edKey.ID || jwk.Crv != "Ed25519" || err != nil {
+		t.Errorf("Expected published Ed25519 key to verify tokens, got %+v: %v", jwk, err)
+	}
+}
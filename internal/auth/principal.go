@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID      uuid.UUID
+	Roles       []string
+	TokenID     string
+	IsChirpyRed bool
+	// SessionID is zero for tokens not tied to a session.
+	SessionID uuid.UUID
+}
+
+// NewPrincipal builds the principal described by validated token claims.
+func NewPrincipal(claims *Claims) (*Principal, error) {
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionID uuid.UUID
+	if claims.SessionID != "" {
+		if sessionID, err = uuid.Parse(claims.SessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Principal{
+		UserID:      userID,
+		Roles:       claims.Roles,
+		TokenID:     claims.ID,
+		IsChirpyRed: claims.IsChirpyRed,
+		SessionID:   sessionID,
+	}, nil
+}
+
+func (p *Principal) HasPermission(perm Permission) bool {
+	return HasPermission(p.Roles, perm)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the caller stored by WithPrincipal, or nil for
+// anonymous requests.
+func PrincipalFrom(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
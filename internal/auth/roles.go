@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"slices"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// Permission names an action guarded by a role check.
+type Permission string
+
+const (
+	PermViewMetrics   Permission = "metrics:view"
+	PermResetPlatform Permission = "platform:reset"
+	PermModerate      Permission = "reports:moderate"
+	PermManageRoles   Permission = "roles:manage"
+	PermManageKeys    Permission = "keys:manage"
+	PermViewAudit     Permission = "audit:view"
+)
+
+var rolePermissions = map[string][]Permission{
+	models.RoleModerator: {PermModerate},
+	models.RoleAdmin:     {PermViewMetrics, PermResetPlatform, PermModerate, PermManageRoles, PermManageKeys, PermViewAudit},
+}
+
+// IsRole reports whether role is one of the roles known to Chirpy.
+func IsRole(role string) bool {
+	return role == models.RoleUser || rolePermissions[role] != nil
+}
+
+// HasPermission reports whether any of roles grants perm.
+func HasPermission(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true
+		}
+	}
+	return false
+}
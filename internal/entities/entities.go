@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 100
+
+var (
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]+)`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([A-Za-z0-9_.+-]+(?:@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+)?)`)
+)
+
+// ExtractHashtags returns the distinct hashtags in body, lowercased and
+// without the leading #, in order of first appearance. Tags made up only of
+// digits or underscores, like #1, are not hashtags.
+func ExtractHashtags(body string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		tag := strings.ToLower(match[1])
+		if seen[tag] || len(tag) > maxHashtagLength || !strings.ContainsFunc(tag, unicode.IsLetter) {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// ExtractMentions returns the distinct @mentions in body without the leading
+// @, in order of first appearance. A mention is either a handle, which is
+// lowercased, or an email address, of which only the domain is lowercased
+// since the local part may be case-sensitive.
+func ExtractMentions(body string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// A sentence ending right after the mention is not part of it.
+		mention := strings.TrimRight(match[1], ".-")
+		if at := strings.LastIndex(mention, "@"); at >= 0 {
+			mention = mention[:at] + strings.ToLower(mention[at:])
+		} else {
+			mention = strings.ToLower(mention)
+		}
+		if mention == "" || seen[mention] {
+			continue
+		}
+		seen[mention] = true
+		mentions = append(mentions, mention)
+	}
+	return mentions
+}
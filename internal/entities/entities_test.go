@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tags := ExtractHashtags("#Go is fun. #go again, #chirpy_dev! not#this &#38; #1 #élan")
+	expected := []string{"go", "chirpy_dev", "élan"}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("Expected %v but got %v", expected, tags)
+	}
+}
+
+func TestExtractMentions(t *testing.T) {
+	mentions := ExtractMentions("hi @Bob, ask @Alice@Example.com. mail me at me@example.com @bob @Alice@example.COM")
+	expected := []string{"bob", "Alice@example.com"}
+	if !slices.Equal(mentions, expected) {
+		t.Errorf("Expected %v but got %v", expected, mentions)
+	}
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	m := NewFileMailer(path)
+
+	for _, subject := range []string{"first", "second"} {
+		err := m.Send(context.Background(), Message{To: "a@example.com", Subject: subject, Body: "body"})
+		if err != nil {
+			t.Fatalf("Expected no error but got error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "Subject: first") || !strings.Contains(content, "Subject: second") {
+		t.Errorf("Expected both messages in file, got:\n%s", content)
+	}
+	if !strings.Contains(content, "To: a@example.com") {
+		t.Errorf("Expected recipient in file, got:\n%s", content)
+	}
+}
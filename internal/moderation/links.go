@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// linkPattern finds links with or without a scheme. The host is the first
+// submatch.
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://)?((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,})\.?(?:[:/?#][^\s]*)?`)
+
+// LinkBlocklist masks or rejects links to blocked domains and their
+// subdomains.
+type LinkBlocklist struct {
+	domains map[string]bool
+	action  Action
+}
+
+func NewLinkBlocklist(domains []string, action Action) *LinkBlocklist {
+	blocked := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		blocked[strings.TrimSuffix(strings.ToLower(domain), ".")] = true
+	}
+	return &LinkBlocklist{
+		domains: blocked,
+		action:  action,
+	}
+}
+
+// LoadLinkBlocklist reads one domain per line. Blank lines and lines
+// starting with # are skipped.
+func LoadLinkBlocklist(path string, action Action) (*LinkBlocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewLinkBlocklist(domains, action), nil
+}
+
+func (l *LinkBlocklist) Moderate(body string) Verdict {
+	matched := false
+	masked := linkPattern.ReplaceAllStringFunc(body, func(link string) string {
+		host := strings.ToLower(linkPattern.FindStringSubmatch(link)[1])
+		if !l.blocked(host) {
+			return link
+		}
+		matched = true
+		return maskText
+	})
+
+	switch {
+	case !matched || l.action == Allow:
+		return Verdict{Action: Allow, Body: body}
+	case l.action == Reject:
+		return Verdict{Action: Reject, Body: body, Reason: "contains a blocked link"}
+	default:
+		return Verdict{Action: Mask, Body: masked}
+	}
+}
+
+func (l *LinkBlocklist) blocked(host string) bool {
+	for {
+		if l.domains[host] {
+			return true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			return false
+		}
+		host = parent
+	}
+}
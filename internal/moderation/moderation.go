@@ -0,0 +1,70 @@
+// Package moderation checks chirp bodies before they are stored. A Pipeline
+// chains Moderator stages, each of which may allow a body, mask parts of it
+// or reject it outright.
+package moderation
+
+// Action is the outcome of moderating a body.
+type Action int
+
+const (
+	Allow Action = iota
+	Mask
+	Reject
+)
+
+// maskText replaces offending text.
+const maskText = "****"
+
+// Verdict is what a Moderator decided. Body holds the masked text when the
+// action is Mask and is the unchanged input otherwise. Reason explains a
+// rejection.
+type Verdict struct {
+	Action Action
+	Body   string
+	Reason string
+}
+
+type Moderator interface {
+	Moderate(body string) Verdict
+}
+
+// Pipeline runs its stages in order. Each stage sees the body as masked by
+// the stages before it, and the first rejection stops the pipeline.
+type Pipeline struct {
+	stages []Moderator
+}
+
+func NewPipeline(stages ...Moderator) *Pipeline {
+	return &Pipeline{
+		stages: stages,
+	}
+}
+
+func (p *Pipeline) Moderate(body string) Verdict {
+	result := Verdict{Action: Allow, Body: body}
+	for _, stage := range p.stages {
+		verdict := stage.Moderate(result.Body)
+		switch verdict.Action {
+		case Reject:
+			verdict.Body = body
+			return verdict
+		case Mask:
+			result.Action = Mask
+			result.Body = verdict.Body
+		}
+	}
+	return result
+}
+
+// ParseAction reads the action names used in the list files and settings.
+func ParseAction(name string) (Action, bool) {
+	switch name {
+	case "allow":
+		return Allow, true
+	case "mask":
+		return Mask, true
+	case "reject":
+		return Reject, true
+	}
+	return Allow, false
+}
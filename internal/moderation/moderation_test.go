@@ -0,0 +1,117 @@
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestWordListMasksNormalizedWords(t *testing.T) {
+	words := NewWordList(map[string]Action{"kerfuffle": Mask})
+
+	verdict := words.Moderate("What a kerfuffle! Such a K3rfuffl3, a ｋｅｒｆｕｆｆｌｅ and a ker\u200bfuffle. 2024")
+	expected := "What a ****! Such a ****, a **** and a ****. 2024"
+	if verdict.Action != Mask || verdict.Body != expected {
+		t.Errorf("Expected mask to %q but got %v %q", expected, verdict.Action, verdict.Body)
+	}
+
+	if verdict := words.Moderate("kerfuffled"); verdict.Action != Allow {
+		t.Errorf("Expected longer word to pass but got %v", verdict.Action)
+	}
+}
+
+func TestPipelineStopsAtReject(t *testing.T) {
+	pipeline := NewPipeline(
+		NewWordList(map[string]Action{"sharbert": Mask}),
+		NewRegexRules(Rule{Pattern: regexp.MustCompile(`\d{3}-\d{4}`), Action: Mask}),
+		NewLinkBlocklist([]string{"spam.example"}, Reject),
+	)
+
+	verdict := pipeline.Moderate("sharbert, call 555-1234")
+	if verdict.Action != Mask || verdict.Body != "****, call ****" {
+		t.Errorf("Expected both stages to mask but got %v %q", verdict.Action, verdict.Body)
+	}
+
+	verdict = pipeline.Moderate("sharbert at https://www.SPAM.example/deal")
+	if verdict.Action != Reject || verdict.Reason == "" {
+		t.Errorf("Expected blocked link to reject with a reason but got %v %q", verdict.Action, verdict.Reason)
+	}
+
+	if verdict := pipeline.Moderate("see notspam.example"); verdict.Action != Allow {
+		t.Errorf("Expected unrelated domain to pass but got %v", verdict.Action)
+	}
+}
+
+func TestNormalizeFoldsLeetspeakAndLookalikes(t *testing.T) {
+	cases := map[string]string{
+		"K3RFUFFL3":          "kerfuffle",
+		"$h@rb3rt":           "sharbert",
+		"ｆｏｒｎａｘ":             "fornax",
+		"fоrnах":             "fornax", // Cyrillic о, а and х
+		"fo\u200brn\u00adax": "fornax",
+		"fórnáx":             "fornax",
+		"e\u0301":            "e",
+		"2024":               "2024",
+	}
+	for word, expected := range cases {
+		if normalized := Normalize(word); normalized != expected {
+			t.Errorf("Expected %q to normalize to %q but got %q", word, expected, normalized)
+		}
+	}
+}
+
+func TestRejectTakesPrecedenceOverMask(t *testing.T) {
+	words := NewWordList(map[string]Action{"kerfuffle": Mask, "fornax": Reject})
+	verdict := words.Moderate("kerfuffle and f0rnax")
+	if verdict.Action != Reject || verdict.Body != "kerfuffle and f0rnax" {
+		t.Errorf("Expected reject with the original body but got %v %q", verdict.Action, verdict.Body)
+	}
+
+	pipeline := NewPipeline(
+		NewWordList(map[string]Action{"kerfuffle": Mask}),
+		NewRegexRules(Rule{Pattern: regexp.MustCompile(`(?i)buy now`), Action: Reject}),
+	)
+	verdict = pipeline.Moderate("kerfuffle, buy now")
+	if verdict.Action != Reject || verdict.Body != "kerfuffle, buy now" {
+		t.Errorf("Expected a later reject to override an earlier mask but got %v %q", verdict.Action, verdict.Body)
+	}
+}
+
+func TestLoadRegexRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	rules := "# comment\n\nmask\t\\d{3}-\\d{4}\nreject  (?i)free money\nallow kerfuffle\n"
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	regexRules, err := LoadRegexRules(path)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if verdict := regexRules.Moderate("call 555-1234 or 555-9876"); verdict.Action != Mask || verdict.Body != "call **** or ****" {
+		t.Errorf("Expected every match to be masked but got %v %q", verdict.Action, verdict.Body)
+	}
+	if verdict := regexRules.Moderate("FREE MONEY here"); verdict.Action != Reject {
+		t.Errorf("Expected rule after double space to reject but got %v", verdict.Action)
+	}
+	if verdict := regexRules.Moderate("kerfuffle"); verdict.Action != Allow {
+		t.Errorf("Expected allow rule to pass but got %v", verdict.Action)
+	}
+
+	if err := os.WriteFile(path, []byte("block \\d+\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRegexRules(path); err == nil {
+		t.Error("Expected unknown action to be an error")
+	}
+}
+
+func TestLinkBlocklistAction(t *testing.T) {
+	if verdict := NewLinkBlocklist([]string{"spam.example"}, Mask).Moderate("see spam.example/x now"); verdict.Action != Mask || verdict.Body != "see **** now" {
+		t.Errorf("Expected link to be masked but got %v %q", verdict.Action, verdict.Body)
+	}
+	if verdict := NewLinkBlocklist([]string{"spam.example"}, Allow).Moderate("see spam.example"); verdict.Action != Allow {
+		t.Errorf("Expected allow action to pass the link but got %v", verdict.Action)
+	}
+}
@@ -0,0 +1,77 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// folds maps accented Latin letters and common Cyrillic and Greek
+// look-alikes to the ASCII letter they are meant to pass for.
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'ř': "r",
+	'ś': "s", 'š': "s", 'ß': "ss",
+	'ť': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+	// Cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'ё': "e", 'і': "i", 'ј': "j", 'к': "k", 'м': "m",
+	'н': "h", 'о': "o", 'р': "p", 'с': "c", 'т': "t", 'у': "y", 'х': "x", 'ѕ': "s",
+	// Greek
+	'α': "a", 'β': "b", 'ε': "e", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o", 'ρ': "p",
+	'τ': "t", 'υ': "u", 'χ': "x",
+}
+
+// leet maps digits and symbols used in place of letters.
+var leet = map[rune]string{
+	'0': "o", '1': "i", '3': "e", '4': "a", '5': "s", '7': "t", '8': "b",
+	'@': "a", '$': "s",
+}
+
+// Normalize folds a word into the form word lists are matched against: lower
+// case ASCII where possible, without invisible characters, combining marks
+// or full-width forms. Leetspeak digits are only read as letters in words
+// that also contain a letter, so plain numbers stay numbers.
+func Normalize(word string) string {
+	hasLetter := strings.ContainsFunc(word, unicode.IsLetter)
+
+	var b strings.Builder
+	for _, r := range word {
+		if isInvisible(r) || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		// Full-width forms U+FF01..U+FF5E mirror ASCII 0x21..0x7E.
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		r = unicode.ToLower(r)
+		if folded, ok := folds[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		if folded, ok := leet[r]; ok && hasLetter {
+			b.WriteString(folded)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isInvisible matches the zero-width and formatting characters used to split
+// a word without changing how it looks.
+func isInvisible(r rune) bool {
+	switch r {
+	case '\u00AD', '\u200B', '\u200C', '\u200D', '\u2060', '\uFEFF':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Rule applies its action to text matching Pattern.
+type Rule struct {
+	Pattern *regexp.Regexp
+	Action  Action
+}
+
+// RegexRules masks or rejects text matching any of its rules. Masking
+// replaces every match, rules that allow do nothing.
+type RegexRules struct {
+	rules []Rule
+}
+
+func NewRegexRules(rules ...Rule) *RegexRules {
+	return &RegexRules{
+		rules: rules,
+	}
+}
+
+// LoadRegexRules reads a rules file. Each line holds "allow", "mask" or
+// "reject", any run of spaces or tabs and a Go regular expression. Blank
+// lines and lines starting with # are skipped.
+func LoadRegexRules(path string) (*RegexRules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []Rule
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		split := strings.IndexFunc(line, unicode.IsSpace)
+		if split < 0 {
+			return nil, fmt.Errorf("%s:%d: expected an action followed by a pattern", path, lineNo)
+		}
+		action, ok := ParseAction(line[:split])
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown action %q", path, lineNo, line[:split])
+		}
+		pattern, err := regexp.Compile(strings.TrimSpace(line[split:]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		rules = append(rules, Rule{Pattern: pattern, Action: action})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewRegexRules(rules...), nil
+}
+
+func (r *RegexRules) Moderate(body string) Verdict {
+	result := Verdict{Action: Allow, Body: body}
+	for _, rule := range r.rules {
+		if rule.Action == Allow || !rule.Pattern.MatchString(result.Body) {
+			continue
+		}
+		if rule.Action == Reject {
+			return Verdict{Action: Reject, Body: body, Reason: "matches a blocked pattern"}
+		}
+		result.Action = Mask
+		result.Body = rule.Pattern.ReplaceAllLiteralString(result.Body, maskText)
+	}
+	return result
+}
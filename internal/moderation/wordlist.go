@@ -0,0 +1,124 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// WordList masks or rejects listed words. Words are compared after
+// Normalize, so "K3rfuffle!" matches "kerfuffle".
+type WordList struct {
+	words map[string]Action
+}
+
+// NewWordList builds a WordList from words and the action each triggers.
+func NewWordList(words map[string]Action) *WordList {
+	normalized := make(map[string]Action, len(words))
+	for word, action := range words {
+		normalized[Normalize(word)] = action
+	}
+	return &WordList{
+		words: normalized,
+	}
+}
+
+// LoadWordList reads a word list file. Each line holds a word optionally
+// followed by "allow", "mask" or "reject", defaultAction being used for
+// words without one. Blank lines and lines starting with # are skipped.
+func LoadWordList(path string, defaultAction Action) (*WordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	words := make(map[string]Action)
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		action := defaultAction
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: expected a word and an optional action", path, lineNo)
+		}
+		if len(fields) == 2 {
+			var ok bool
+			if action, ok = ParseAction(fields[1]); !ok {
+				return nil, fmt.Errorf("%s:%d: unknown action %q", path, lineNo, fields[1])
+			}
+		}
+		words[fields[0]] = action
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewWordList(words), nil
+}
+
+func (l *WordList) Moderate(body string) Verdict {
+	var b strings.Builder
+	masked := false
+	last := 0
+	for _, span := range wordSpans(body) {
+		word := body[span[0]:span[1]]
+		action, ok := l.lookup(word)
+		if !ok || action == Allow {
+			continue
+		}
+		if action == Reject {
+			return Verdict{Action: Reject, Body: body, Reason: "contains a blocked word"}
+		}
+		b.WriteString(body[last:span[0]])
+		b.WriteString(maskText)
+		last = span[1]
+		masked = true
+	}
+
+	if !masked {
+		return Verdict{Action: Allow, Body: body}
+	}
+	b.WriteString(body[last:])
+	return Verdict{Action: Mask, Body: b.String()}
+}
+
+func (l *WordList) lookup(word string) (Action, bool) {
+	if action, ok := l.words[Normalize(word)]; ok {
+		return action, true
+	}
+	// "@word" is a mention of word, not leetspeak for "aword".
+	if rest, found := strings.CutPrefix(word, "@"); found {
+		action, ok := l.words[Normalize(rest)]
+		return action, ok
+	}
+	return Allow, false
+}
+
+// wordSpans returns the byte ranges of the words in body. Letters, digits,
+// combining marks, invisible characters and the leetspeak symbols @ and $
+// make up words, anything else separates them.
+func wordSpans(body string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range body {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) ||
+			isInvisible(r) || r == '@' || r == '$'
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(body)})
+	}
+	return spans
+}
@@ -0,0 +1,351 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "chirpy"
+	testClientSecret = "s3cr3t"
+	testRedirectURL  = "http://localhost:8080/api/auth/fake/callback"
+)
+
+// fakeProvider is a minimal OpenID Connect provider. Its authorization
+// endpoint signs in the same user every time and redirects straight back
+// with a code.
+type fakeProvider struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	kid    string
+	codes  map[string]authRequest
+	claims jwt.MapClaims
+}
+
+type authRequest struct {
+	challenge string
+	nonce     string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	f := &fakeProvider{
+		codes: make(map[string]authRequest),
+		claims: jwt.MapClaims{
+			"sub":            "user-123",
+			"email":          "a@example.com",
+			"email_verified": true,
+		},
+	}
+	f.rotateKey(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /authorize", f.handleAuthorize)
+	mux.HandleFunc("POST /token", f.handleToken)
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": f.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+			}},
+		})
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeProvider) rotateKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.key = key
+	f.kid = kid
+}
+
+func (f *fakeProvider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != testClientID || query.Get("redirect_uri") != testRedirectURL ||
+		query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := rand.Text()
+	f.mu.Lock()
+	f.codes[code] = authRequest{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	f.mu.Unlock()
+
+	redirect := testRedirectURL + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (f *fakeProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, _ := r.BasicAuth()
+	if clientID != testClientID || clientSecret != testClientSecret {
+		writeTokenError(w, "invalid_client")
+		return
+	}
+
+	f.mu.Lock()
+	request, ok := f.codes[r.PostFormValue("code")]
+	delete(f.codes, r.PostFormValue("code"))
+	f.mu.Unlock()
+	if !ok || r.PostFormValue("redirect_uri") != testRedirectURL {
+		writeTokenError(w, "invalid_grant")
+		return
+	}
+	if S256Challenge(r.PostFormValue("code_verifier")) != request.challenge {
+		writeTokenError(w, "invalid_grant")
+		return
+	}
+
+	json.NewEncoder(w).Encode(Token{
+		AccessToken: "access",
+		TokenType:   "Bearer",
+		IDToken:     f.idToken(jwt.MapClaims{"nonce": request.nonce}),
+		ExpiresIn:   3600,
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, code string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+// idToken signs the provider's claims for the test client, with overrides
+// applied on top.
+func (f *fakeProvider) idToken(overrides jwt.MapClaims) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": f.server.URL,
+		"aud": testClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for name, value := range f.claims {
+		claims[name] = value
+	}
+	for name, value := range overrides {
+		claims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = f.kid
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (f *fakeProvider) provider() *Provider {
+	return NewProvider(Config{
+		Name:         "fake",
+		Issuer:       f.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	}, f.server.Client())
+}
+
+// authorize follows the authorization URL like a browser would and returns
+// the code and state handed to the callback.
+func authorize(t *testing.T, provider *Provider, state, nonce, challenge string) (string, string) {
+	t.Helper()
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, challenge)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected redirect from authorization endpoint but got status %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	fake := newFakeProvider(t)
+	provider := fake.provider()
+	ctx := context.Background()
+
+	verifier, err := GenerateVerifier()
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	code, state := authorize(t, provider, "state-1", "nonce-1", S256Challenge(verifier))
+	if state != "state-1" {
+		t.Errorf("Expected state to round trip but got %q", state)
+	}
+
+	token, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "a@example.com" || !claims.EmailVerified {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	if _, err := provider.Exchange(ctx, code, verifier); err == nil {
+		t.Error("Expected code to be usable only once")
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	fake := newFakeProvider(t)
+	provider := fake.provider()
+
+	verifier, _ := GenerateVerifier()
+	otherVerifier, _ := GenerateVerifier()
+	code, _ := authorize(t, provider, "state", "nonce", S256Challenge(verifier))
+
+	_, err := provider.Exchange(context.Background(), code, otherVerifier)
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Expected invalid_grant but got: %v", err)
+	}
+}
+
+func TestS256ChallengeRFC7636Vector(t *testing.T) {
+	// RFC 7636 appendix B.
+	got := S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("S256Challenge = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	fake := newFakeProvider(t)
+	provider := fake.provider()
+
+	hs256, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": fake.server.URL, "aud": testClientID, "sub": "user-123", "nonce": "nonce",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testClientSecret))
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"iss": fake.server.URL, "aud": testClientID, "sub": "user-123", "nonce": "nonce",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong nonce", fake.idToken(jwt.MapClaims{"nonce": "other"})},
+		{"wrong audience", fake.idToken(jwt.MapClaims{"nonce": "nonce", "aud": "someone-else"})},
+		{"other authorized party", fake.idToken(jwt.MapClaims{"nonce": "nonce", "aud": []string{testClientID, "other"}, "azp": "other"})},
+		{"wrong issuer", fake.idToken(jwt.MapClaims{"nonce": "nonce", "iss": "https://evil.example.com"})},
+		{"expired", fake.idToken(jwt.MapClaims{"nonce": "nonce", "exp": time.Now().Add(-time.Hour).Unix()})},
+		{"no expiry", fake.idToken(jwt.MapClaims{"nonce": "nonce", "exp": nil})},
+		{"no subject", fake.idToken(jwt.MapClaims{"nonce": "nonce", "sub": ""})},
+		{"signed with client secret", hs256},
+		{"unsigned", unsigned},
+	}
+	for _, tt := range tests {
+		if _, err := provider.VerifyIDToken(context.Background(), tt.token, "nonce"); err == nil {
+			t.Errorf("%s: expected token to be rejected", tt.name)
+		}
+	}
+
+	if _, err := provider.VerifyIDToken(context.Background(), fake.idToken(jwt.MapClaims{"nonce": "nonce"}), "nonce"); err != nil {
+		t.Errorf("Expected valid token to be accepted but got error: %v", err)
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	fake := newFakeProvider(t)
+	provider := fake.provider()
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, fake.idToken(jwt.MapClaims{"nonce": "n"}), "n"); err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+
+	fake.rotateKey(t, "key-2")
+	rotated := fake.idToken(jwt.MapClaims{"nonce": "n"})
+	if _, err := provider.VerifyIDToken(ctx, rotated, "n"); err == nil {
+		t.Error("Expected unknown key to be refused until the refresh interval passed")
+	}
+
+	provider.mu.Lock()
+	provider.keysFetchedAt = time.Now().Add(-keysRefreshInterval)
+	provider.mu.Unlock()
+	if _, err := provider.VerifyIDToken(ctx, rotated, "n"); err != nil {
+		t.Errorf("Expected rotated key to be fetched but got error: %v", err)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	fake := newFakeProvider(t)
+	provider := NewProvider(Config{
+		Name:     "fake",
+		Issuer:   fake.server.URL + "/",
+		ClientID: testClientID,
+	}, fake.server.Client())
+
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge"); err == nil {
+		t.Error("Expected issuer mismatch to be rejected")
+	}
+}
+
+func TestEmailVerifiedAsString(t *testing.T) {
+	var claims IDToken
+	if err := json.Unmarshal([]byte(`{"email_verified":"true"}`), &claims); err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if !claims.EmailVerified {
+		t.Error("Expected \"true\" to count as verified")
+	}
+}
@@ -0,0 +1,97 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Cursor marks the last row of a page in a listing ordered by
+// (created_at, id). Clients only ever see its opaque encoded form.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor previously produced by Encode. An empty string
+// means "start from the beginning" and yields a nil cursor.
+func DecodeCursor(value string) (*Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// ParseLimit reads a page size from a query parameter, falling back to
+// DefaultLimit and capping at MaxLimit.
+func ParseLimit(value string) (int, error) {
+	if value == "" {
+		return DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return limit, nil
+}
+
+type offsetCursor struct {
+	Offset int `json:"o"`
+}
+
+// EncodeOffset builds an opaque cursor for listings that are ordered by a
+// computed score, such as search results, where keyset paging does not apply.
+func EncodeOffset(offset int) string {
+	data, _ := json.Marshal(offsetCursor{Offset: offset})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeOffset parses a cursor produced by EncodeOffset. An empty string
+// yields offset 0.
+func DecodeOffset(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+
+	var cursor offsetCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return cursor.Offset, nil
+}
@@ -0,0 +1,167 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// PrefixLength is how many hex digits of a password's SHA-1 hash are used
+// to look up its range. Only the prefix leaves Breached, so the password
+// cannot be told from the others sharing the range (k-anonymity).
+const PrefixLength = 5
+
+// RangeSource returns the upper case hash suffixes of the breached
+// passwords whose SHA-1 hash starts with prefix, like the Pwned Passwords
+// range API.
+type RangeSource interface {
+	Range(prefix string) ([]string, error)
+}
+
+// Breached reports whether password appears in source. The source only
+// ever sees the first PrefixLength digits of the password's hash.
+func Breached(source RangeSource, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+
+	suffixes, err := source.Range(prefix)
+	if err != nil {
+		return false, err
+	}
+	for _, candidate := range suffixes {
+		if candidate == suffix {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HashFile is a RangeSource reading a local copy of the Pwned Passwords
+// list ordered by hash, one HASH:COUNT line per password. The file is too
+// big to load, so ranges are found by binary search. It is safe for
+// concurrent use.
+type HashFile struct {
+	file *os.File
+	size int64
+}
+
+func OpenHashFile(path string) (*HashFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &HashFile{file: file, size: info.Size()}, nil
+}
+
+func (f *HashFile) Close() error {
+	return f.file.Close()
+}
+
+func (f *HashFile) Range(prefix string) ([]string, error) {
+	if len(prefix) != PrefixLength {
+		return nil, errors.New("hash prefix must be 5 hex digits")
+	}
+	prefix = strings.ToUpper(prefix)
+
+	start, err := f.rangeStart(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var suffixes []string
+	reader := bufio.NewReader(io.NewSectionReader(f.file, start, f.size-start))
+	for {
+		line, err := reader.ReadString('\n')
+		hash := lineHash(line)
+		if hash != "" {
+			if !strings.HasPrefix(hash, prefix) {
+				break
+			}
+			suffixes = append(suffixes, hash[PrefixLength:])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return suffixes, nil
+}
+
+// rangeStart returns the offset of the first line whose hash is not
+// smaller than prefix.
+func (f *HashFile) rangeStart(prefix string) (int64, error) {
+	low, high := int64(0), f.size
+	for low < high {
+		mid := low + (high-low)/2
+		offset, hash, err := f.lineAfter(mid)
+		if err != nil {
+			return 0, err
+		}
+		if hash == "" || hash[:PrefixLength] >= prefix {
+			high = mid
+		} else {
+			low = offset + 1
+		}
+	}
+
+	offset, _, err := f.lineAfter(low)
+	return offset, err
+}
+
+// lineAfter returns the start of the first line beginning at or after
+// offset, and the hash on it. The hash is empty past the last line.
+func (f *HashFile) lineAfter(offset int64) (int64, string, error) {
+	reader := bufio.NewReader(io.NewSectionReader(f.file, offset, f.size-offset))
+	start := offset
+	if offset > 0 {
+		// The line the offset falls into belongs to the search below it,
+		// unless the offset is right after a line break.
+		previous := make([]byte, 1)
+		if _, err := f.file.ReadAt(previous, offset-1); err != nil {
+			return 0, "", err
+		}
+		if previous[0] != '\n' {
+			skipped, err := reader.ReadBytes('\n')
+			start += int64(len(skipped))
+			if err == io.EOF {
+				return f.size, "", nil
+			}
+			if err != nil {
+				return 0, "", err
+			}
+		}
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, lineHash(line), nil
+}
+
+// lineHash returns the upper case hash of a HASH:COUNT line, or nothing
+// when the line holds none.
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	if len(hash) <= PrefixLength || !isHex([]byte(hash)) {
+		return ""
+	}
+	return strings.ToUpper(hash)
+}
+
+func isHex(b []byte) bool {
+	return len(bytes.Trim(b, "0123456789abcdefABCDEF")) == 0
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{MinLength: 10, MaxLength: MaxBcryptLength, MinClasses: 3}
+
+	if problems := policy.Check("Correct-horse-42", "walt@example.com"); len(problems) != 0 {
+		t.Errorf("Expected strong password to pass but got %v", problems)
+	}
+	if problems := policy.Check("", "walt@example.com"); len(problems) != 1 {
+		t.Errorf("Expected a single problem for an empty password but got %v", problems)
+	}
+	if problems := policy.Check("short", "walt@example.com"); len(problems) != 2 {
+		t.Errorf("Expected length and class problems but got %v", problems)
+	}
+	if problems := policy.Check("Hello-WALT-2024", "walt@example.com"); len(problems) != 1 {
+		t.Errorf("Expected email problem but got %v", problems)
+	}
+	if problems := policy.Check("Hello-JO-2024-x", "jo@example.com"); len(problems) != 0 {
+		t.Errorf("Expected short local part to be ignored but got %v", problems)
+	}
+	if problems := policy.Check(strings.Repeat("Aa1-", 19), ""); len(problems) != 1 {
+		t.Errorf("Expected password over the bcrypt limit to fail but got %v", problems)
+	}
+}
+
+func TestHashFileBreached(t *testing.T) {
+	var lines []string
+	for _, breached := range []string{"password", "123456", "qwerty", "letmein", "hunter2"} {
+		sum := sha1.Sum([]byte(breached))
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":42")
+	}
+	// Neighbours sharing a prefix with "password" and at the file's edges.
+	lines = append(lines,
+		"5BAA6"+strings.Repeat("0", 35)+":1",
+		"5BAA6"+strings.Repeat("F", 35)+":1",
+		strings.Repeat("0", 40)+":1",
+		strings.Repeat("F", 40)+":1",
+	)
+	slices.Sort(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := OpenHashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, password := range []string{"password", "123456", "qwerty", "letmein", "hunter2"} {
+		breached, err := Breached(file, password)
+		if err != nil || !breached {
+			t.Errorf("Expected %q to be breached but got %v, %v", password, breached, err)
+		}
+	}
+	for _, password := range []string{"Correct-horse-42", "passw0rd!"} {
+		breached, err := Breached(file, password)
+		if err != nil || breached {
+			t.Errorf("Expected %q not to be breached but got %v, %v", password, breached, err)
+		}
+	}
+
+	suffixes, err := file.Range("5baa6")
+	if err != nil || len(suffixes) != 3 {
+		t.Errorf("Expected 3 suffixes in range 5BAA6 but got %v, %v", suffixes, err)
+	}
+	if suffixes, err := file.Range("FFFFF"); err != nil || len(suffixes) != 1 {
+		t.Errorf("Expected the last line in range FFFFF but got %v, %v", suffixes, err)
+	}
+}
@@ -0,0 +1,101 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxBcryptLength is the most bytes of a password bcrypt looks at. Longer
+// passwords are refused rather than silently cut short.
+const MaxBcryptLength = 72
+
+// minEmailPartLength keeps short local parts like "jo" from ruling out
+// every password that happens to contain them.
+const minEmailPartLength = 3
+
+// Policy is what a new password has to satisfy.
+type Policy struct {
+	// MinLength and MaxLength count characters and bytes respectively,
+	// MaxLength is capped at MaxBcryptLength.
+	MinLength int
+	MaxLength int
+	// MinClasses is how many of lower case letters, upper case letters,
+	// digits and symbols the password has to mix. Zero disables the rule.
+	MinClasses int
+}
+
+var DefaultPolicy = Policy{
+	MinLength:  8,
+	MaxLength:  MaxBcryptLength,
+	MinClasses: 0,
+}
+
+// Check returns what is wrong with password for the account with email, or
+// nothing when it satisfies the policy.
+func (p Policy) Check(password, email string) []string {
+	if password == "" {
+		return []string{"password cannot be empty"}
+	}
+
+	var problems []string
+	if utf8.RuneCountInString(password) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+	maxLength := p.MaxLength
+	if maxLength <= 0 || maxLength > MaxBcryptLength {
+		maxLength = MaxBcryptLength
+	}
+	if len(password) > maxLength {
+		problems = append(problems, fmt.Sprintf("password must be at most %d bytes long", maxLength))
+	}
+	if p.MinClasses > 0 && characterClasses(password) < p.MinClasses {
+		problems = append(problems, fmt.Sprintf("password must mix at least %d of lower case letters, upper case letters, digits and symbols", p.MinClasses))
+	}
+	if containsEmail(password, email) {
+		problems = append(problems, "password must not contain the email address")
+	}
+
+	return problems
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, symbol} {
+		if has {
+			classes++
+		}
+	}
+	return classes
+}
+
+// containsEmail reports whether password contains the email or the part
+// of it before the @, ignoring case.
+func containsEmail(password, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	password = strings.ToLower(password)
+
+	if strings.Contains(password, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return utf8.RuneCountInString(local) >= minEmailPartLength && strings.Contains(password, local)
+}
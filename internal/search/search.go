@@ -0,0 +1,124 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Term is a single search condition. A term with several words is a phrase
+// whose words must appear next to each other in order. When Prefix is set the
+// last word only has to be a prefix of a word in the text.
+type Term struct {
+	Words  []string
+	Prefix bool
+}
+
+type Query struct {
+	Terms []Term
+}
+
+// ParseQuery turns user input into a Query. Double quoted parts become
+// phrases, a trailing * on a word makes it a prefix match and everything
+// else is matched word by word. All terms must match.
+func ParseQuery(input string) (*Query, error) {
+	var query Query
+
+	for i, part := range strings.Split(input, `"`) {
+		if i%2 == 1 {
+			words := Tokenize(part)
+			if len(words) > 0 {
+				query.Terms = append(query.Terms, Term{Words: words})
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			prefix := strings.HasSuffix(field, "*")
+			words := Tokenize(field)
+			if len(words) == 0 {
+				continue
+			}
+			// Punctuation inside a word like "don't" splits it, keep the
+			// pieces together as a phrase.
+			query.Terms = append(query.Terms, Term{Words: words, Prefix: prefix})
+		}
+	}
+
+	if len(query.Terms) == 0 {
+		return nil, errors.New("search query must contain at least one word")
+	}
+
+	return &query, nil
+}
+
+// Tokenize lowercases text and splits it into words of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// TSQuery renders the query in Postgres to_tsquery syntax. Words only ever
+// contain letters and digits, so the result is safe to pass as a parameter.
+func (q *Query) TSQuery() string {
+	terms := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		words := make([]string, len(term.Words))
+		copy(words, term.Words)
+		if term.Prefix {
+			words[len(words)-1] += ":*"
+		}
+		terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// Rank scores text against the query for stores without a full-text engine.
+// It returns 0 when any term is missing, otherwise the number of term
+// occurrences scaled down by the length of the text.
+func (q *Query) Rank(text string) float64 {
+	words := Tokenize(text)
+	if len(words) == 0 {
+		return 0
+	}
+
+	hits := 0
+	for _, term := range q.Terms {
+		count := term.count(words)
+		if count == 0 {
+			return 0
+		}
+		hits += count
+	}
+
+	return float64(hits) / float64(len(words))
+}
+
+func (t Term) count(words []string) int {
+	count := 0
+	for start := 0; start+len(t.Words) <= len(words); start++ {
+		if t.matchesAt(words, start) {
+			count++
+		}
+	}
+	return count
+}
+
+func (t Term) matchesAt(words []string, start int) bool {
+	last := len(t.Words) - 1
+	for i, word := range t.Words {
+		candidate := words[start+i]
+		if i == last && t.Prefix {
+			if !strings.HasPrefix(candidate, word) {
+				return false
+			}
+			continue
+		}
+		if candidate != word {
+			return false
+		}
+	}
+	return true
+}
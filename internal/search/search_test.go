@@ -0,0 +1,32 @@
+package search
+
+import "testing"
+
+func TestTSQuery(t *testing.T) {
+	query, err := ParseQuery(`"hello world" go* chirpy's`)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+
+	expected := "(hello <-> world) & (go:*) & (chirpy <-> s)"
+	if query.TSQuery() != expected {
+		t.Errorf("Expected %q but got %q", expected, query.TSQuery())
+	}
+}
+
+func TestRank(t *testing.T) {
+	query, _ := ParseQuery(`"hello world" go*`)
+
+	if rank := query.Rank("Hello, world! Going home"); rank == 0 {
+		t.Error("Expected phrase and prefix to match")
+	}
+	if rank := query.Rank("world hello going"); rank != 0 {
+		t.Errorf("Expected phrase out of order not to match but got rank %v", rank)
+	}
+}
+
+func TestParseQueryRejectsEmpty(t *testing.T) {
+	if _, err := ParseQuery(` "" * `); err == nil {
+		t.Error("Expected error for query without words")
+	}
+}
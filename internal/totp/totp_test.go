@@ -0,0 +1,106 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcKey is the SHA-1 key used by the test vectors in RFC 4226 and RFC 6238.
+var rfcKey = []byte("12345678901234567890")
+
+func TestHOTPRFC4226Vectors(t *testing.T) {
+	// RFC 4226 appendix D.
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, want := range expected {
+		if got := HOTP(rfcKey, uint64(counter), 6); got != want {
+			t.Errorf("HOTP(counter=%d) = %s, want %s", counter, got, want)
+		}
+	}
+}
+
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1 rows.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.unix, 0))
+		if got := HOTP(rfcKey, uint64(step), 8); got != tt.want {
+			t.Errorf("TOTP(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	for _, offset := range []time.Duration{-Period, 0, Period} {
+		code, _ := Code(secret, now.Add(offset))
+		step, ok := Validate(secret, code, now)
+		if !ok {
+			t.Errorf("Expected code from offset %s to be accepted", offset)
+		}
+		if step != Step(now.Add(offset)) {
+			t.Errorf("Expected step %d but got %d", Step(now.Add(offset)), step)
+		}
+	}
+
+	code, _ := Code(secret, now.Add(2*Period))
+	if _, ok := Validate(secret, code, now); ok {
+		t.Error("Expected code two steps ahead to be rejected")
+	}
+	if _, ok := Validate(secret, "12345", now); ok {
+		t.Error("Expected short code to be rejected")
+	}
+}
+
+func TestDecodeSecretLenient(t *testing.T) {
+	secret, _ := GenerateSecret()
+	spaced := strings.ToLower(secret[:8]) + " " + secret[8:]
+
+	a, err := DecodeSecret(secret)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	b, err := DecodeSecret(spaced)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if string(a) != string(b) || len(a) != SecretSize {
+		t.Errorf("Expected the same %d byte key from both spellings", SecretSize)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Chirpy", "a@example.com", "JBSWY3DPEHPK3PXP")
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("Expected no error but got error: %v", err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("Unexpected URI %s", uri)
+	}
+	if parsed.Path != "/Chirpy:a@example.com" {
+		t.Errorf("Unexpected label %s", parsed.Path)
+	}
+	if parsed.Query().Get("secret") != "JBSWY3DPEHPK3PXP" || parsed.Query().Get("issuer") != "Chirpy" {
+		t.Errorf("Unexpected parameters %s", parsed.RawQuery)
+	}
+}
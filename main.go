@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/mailer"
+	"github.com/karaMuha/go-chirpy/internal/moderation"
+	"github.com/karaMuha/go-chirpy/internal/oidc"
+	"github.com/karaMuha/go-chirpy/internal/password"
 	"github.com/karaMuha/go-chirpy/rest"
 	"github.com/karaMuha/go-chirpy/service"
 	"github.com/karaMuha/go-chirpy/sql/repositories"
@@ -21,10 +30,270 @@ func main() {
 	dbURL := os.Getenv("DB_URL")
 	platform := os.Getenv("PLATFORM")
 	polkaKey := os.Getenv("POLKA_KEY")
+	storage := os.Getenv("STORAGE")
+	baseURL := os.Getenv("APP_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	editWindow := service.EditWindow{
+		Default: durationEnv("CHIRP_EDIT_WINDOW"),
+		Red:     durationEnv("CHIRP_EDIT_WINDOW_RED"),
+	}
 
 	appState := state.NewAppState(platform)
-	appState.Secret = secret
+	// SECRET keeps verifying tokens issued before keys had ids, and signs
+	// until the keyring is loaded.
+	appState.Keys = auth.NewKeyring(secret)
 	appState.PolkaKey = polkaKey
+	appState.AdminEmails = listEnv("ADMIN_EMAILS")
+
+	stores := setupStores(storage, dbURL)
+
+	mailSender := setupMailer()
+	passwordPolicyService := setupPasswordPolicy()
+	revocationService := service.NewTokenRevocationService(stores.deniedTokens, stores.refreshTokens, appState.Denylist)
+	loginThrottleService := service.NewLoginThrottleService(stores.loginAttempts, stores.userTokens, stores.users, mailSender, baseURL, durationEnv("ACCOUNT_UNLOCK_TTL"))
+	verificationService := service.NewEmailVerificationService(stores.userTokens, stores.users, mailSender, baseURL, durationEnv("EMAIL_VERIFICATION_TTL"))
+	userService := service.NewUsersService(stores.users, appState, stores.refreshTokens, &verificationService, stores.twoFactor, &revocationService, &loginThrottleService, &passwordPolicyService)
+	chripsService := service.NewChripsService(stores.chirps, stores.likes, stores.tags, stores.users, editWindow, os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true")
+	followsService := service.NewFollowsService(stores.follows, stores.users, stores.chirps, stores.likes)
+	likesService := service.NewLikesService(stores.likes, stores.chirps, stores.users)
+	reportsService := service.NewReportsService(stores.reports, stores.chirps, stores.users, &revocationService)
+	passwordResetService := service.NewPasswordResetService(stores.userTokens, stores.users, &revocationService, &passwordPolicyService, mailSender, baseURL, durationEnv("PASSWORD_RESET_TTL"))
+	oidcService := service.NewOIDCService(setupOIDCProviders(baseURL), stores.identities, &userService)
+	signingKeysService := service.NewSigningKeysService(stores.signingKeys, appState.Keys, os.Getenv("JWT_ALGORITHM"), durationEnv("JWT_KEY_ROTATION"), durationEnv("JWT_KEY_RETENTION"))
+	service := service.NewService(setupModeration())
+
+	if respErr := signingKeysService.Load(context.Background()); respErr != nil {
+		log.Fatalf("Could not load signing keys: %s", respErr.Error)
+	}
+	go signingKeysService.RunRotation(context.Background())
+	if respErr := revocationService.Load(context.Background()); respErr != nil {
+		log.Fatalf("Could not load denied tokens: %s", respErr.Error)
+	}
+	go revocationService.RunSync(context.Background())
+
+	restHandler := rest.NewRestHandler(appState, service, userService, chripsService, followsService, likesService, reportsService, passwordResetService, verificationService, oidcService, signingKeysService, loginThrottleService)
+	mux := http.NewServeMux()
+	setupEndpoints(mux, restHandler, appState)
+
+	server := http.Server{
+		Addr:    ":8080",
+		Handler: rest.WithRequestID(mux),
+	}
+
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("Could not start server: %v", err)
+	}
+}
+
+// durationEnv parses an optional duration setting such as "15m". Unset
+// means zero.
+func durationEnv(name string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		log.Fatalf("Invalid %s %q: must be a duration like 15m", name, value)
+	}
+	return duration
+}
+
+// intEnv parses an optional non-negative number setting, returning
+// fallback when it is unset.
+func intEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		log.Fatalf("Invalid %s %q: must be a number", name, value)
+	}
+	return number
+}
+
+// listEnv splits an optional comma separated setting, dropping blanks.
+func listEnv(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// setupMailer picks where outgoing email goes. MAIL_FILE appends messages to
+// a file, otherwise they are logged.
+func setupMailer() mailer.Mailer {
+	if path := os.Getenv("MAIL_FILE"); path != "" {
+		return mailer.NewFileMailer(path)
+	}
+	return mailer.NewLogMailer()
+}
+
+// setupOIDCProviders registers the OpenID Connect providers named in
+// OIDC_PROVIDERS. Each one is configured through OIDC_<NAME>_ISSUER,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and optionally
+// OIDC_<NAME>_SCOPES.
+func setupOIDCProviders(baseURL string) []*oidc.Provider {
+	var providers []*oidc.Provider
+	for _, name := range listEnv("OIDC_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := oidc.Config{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  baseURL + "/api/auth/" + name + "/callback",
+			Scopes:       listEnv(prefix + "SCOPES"),
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			log.Fatalf("OIDC provider %q needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+		providers = append(providers, oidc.NewProvider(config, &http.Client{Timeout: 10 * time.Second}))
+	}
+	return providers
+}
+
+// setupModeration chains the moderation stages configured through the
+// environment. Without MODERATION_WORDS the built-in word list is used.
+// MODERATION_WORD_ACTION applies to listed words without their own action
+// and MODERATION_LINK_ACTION to blocked links. Both take allow, mask or
+// reject.
+func setupModeration() *moderation.Pipeline {
+	var stages []moderation.Moderator
+
+	wordAction := actionEnv("MODERATION_WORD_ACTION", moderation.Mask)
+	linkAction := actionEnv("MODERATION_LINK_ACTION", moderation.Reject)
+	if path := os.Getenv("MODERATION_WORDS"); path != "" {
+		words, err := moderation.LoadWordList(path, wordAction)
+		if err != nil {
+			log.Fatalf("Could not load word list: %v", err)
+		}
+		stages = append(stages, words)
+	} else {
+		stages = append(stages, service.DefaultWordList(wordAction))
+	}
+
+	if path := os.Getenv("MODERATION_RULES"); path != "" {
+		rules, err := moderation.LoadRegexRules(path)
+		if err != nil {
+			log.Fatalf("Could not load moderation rules: %v", err)
+		}
+		stages = append(stages, rules)
+	}
+
+	if path := os.Getenv("MODERATION_BLOCKED_LINKS"); path != "" {
+		links, err := moderation.LoadLinkBlocklist(path, linkAction)
+		if err != nil {
+			log.Fatalf("Could not load link blocklist: %v", err)
+		}
+		stages = append(stages, links)
+	}
+
+	return moderation.NewPipeline(stages...)
+}
+
+// actionEnv parses an optional moderation action setting, returning
+// fallback when it is unset.
+func actionEnv(name string, fallback moderation.Action) moderation.Action {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	action, ok := moderation.ParseAction(value)
+	if !ok {
+		log.Fatalf("Invalid %s %q: must be allow, mask or reject", name, value)
+	}
+	return action
+}
+
+// setupPasswordPolicy configures what passwords users may pick through
+// PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH and PASSWORD_MIN_CLASSES.
+// PASSWORD_BREACHED_HASHES names a local copy of the Pwned Passwords list
+// ordered by hash to refuse breached passwords with.
+func setupPasswordPolicy() service.PasswordPolicyService {
+	policy := password.Policy{
+		MinLength:  intEnv("PASSWORD_MIN_LENGTH", password.DefaultPolicy.MinLength),
+		MaxLength:  intEnv("PASSWORD_MAX_LENGTH", password.DefaultPolicy.MaxLength),
+		MinClasses: intEnv("PASSWORD_MIN_CLASSES", password.DefaultPolicy.MinClasses),
+	}
+	if policy.MaxLength > password.MaxBcryptLength {
+		log.Fatalf("Invalid PASSWORD_MAX_LENGTH %d: bcrypt allows at most %d bytes", policy.MaxLength, password.MaxBcryptLength)
+	}
+	if policy.MinClasses > 4 {
+		log.Fatalf("Invalid PASSWORD_MIN_CLASSES %d: there are 4 character classes", policy.MinClasses)
+	}
+
+	var breached password.RangeSource
+	if path := os.Getenv("PASSWORD_BREACHED_HASHES"); path != "" {
+		hashes, err := password.OpenHashFile(path)
+		if err != nil {
+			log.Fatalf("Could not open breached password hashes: %v", err)
+		}
+		breached = hashes
+	}
+
+	return service.NewPasswordPolicyService(policy, breached)
+}
+
+type stores struct {
+	chirps        repositories.ChirpsStore
+	users         repositories.UsersStore
+	refreshTokens repositories.RefreshTokenStore
+	follows       repositories.FollowsStore
+	likes         repositories.LikesStore
+	tags          repositories.TagsStore
+	reports       repositories.ReportsStore
+	userTokens    repositories.UserTokensStore
+	twoFactor     repositories.TwoFactorStore
+	identities    repositories.IdentitiesStore
+	signingKeys   repositories.SigningKeysStore
+	deniedTokens  repositories.DeniedTokensStore
+	loginAttempts repositories.LoginAttemptsStore
+}
+
+// setupStores returns the Postgres backed repositories, or in-memory ones
+// when storage is "memory" so the server can run without a database.
+func setupStores(storage, dbURL string) stores {
+	if storage == "memory" {
+		log.Println("Using in-memory storage, data is lost on restart")
+		memDB := repositories.NewMemoryDB()
+		chirpRepo := repositories.NewMemoryChirpsRepository(memDB)
+		userRepo := repositories.NewMemoryUsersRepository(memDB)
+		refreshTokenRepo := repositories.NewMemoryRefreshTokenRepository(memDB)
+		followsRepo := repositories.NewMemoryFollowsRepository(memDB)
+		likesRepo := repositories.NewMemoryLikesRepository(memDB)
+		tagsRepo := repositories.NewMemoryTagsRepository(memDB)
+		reportsRepo := repositories.NewMemoryReportsRepository(memDB)
+		userTokensRepo := repositories.NewMemoryUserTokensRepository(memDB)
+		twoFactorRepo := repositories.NewMemoryTwoFactorRepository(memDB)
+		identitiesRepo := repositories.NewMemoryIdentitiesRepository(memDB)
+		signingKeysRepo := repositories.NewMemorySigningKeysRepository(memDB)
+		deniedTokensRepo := repositories.NewMemoryDeniedTokensRepository(memDB)
+		loginAttemptsRepo := repositories.NewMemoryLoginAttemptsRepository(memDB)
+
+		return stores{
+			chirps:        &chirpRepo,
+			users:         &userRepo,
+			refreshTokens: &refreshTokenRepo,
+			follows:       &followsRepo,
+			likes:         &likesRepo,
+			tags:          &tagsRepo,
+			reports:       &reportsRepo,
+			userTokens:    &userTokensRepo,
+			twoFactor:     &twoFactorRepo,
+			identities:    &identitiesRepo,
+			signingKeys:   &signingKeysRepo,
+			deniedTokens:  &deniedTokensRepo,
+			loginAttempts: &loginAttemptsRepo,
+		}
+	}
 
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
@@ -38,22 +307,31 @@ func main() {
 	chirpRepo := repositories.NewChirpsRepository(db)
 	userRepo := repositories.NewUsersRepository(db)
 	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	followsRepo := repositories.NewFollowsRepository(db)
+	likesRepo := repositories.NewLikesRepository(db)
+	tagsRepo := repositories.NewTagsRepository(db)
+	reportsRepo := repositories.NewReportsRepository(db)
+	userTokensRepo := repositories.NewUserTokensRepository(db)
+	twoFactorRepo := repositories.NewTwoFactorRepository(db)
+	identitiesRepo := repositories.NewIdentitiesRepository(db)
+	signingKeysRepo := repositories.NewSigningKeysRepository(db)
+	deniedTokensRepo := repositories.NewDeniedTokensRepository(db)
+	loginAttemptsRepo := repositories.NewLoginAttemptsRepository(db)
 
-	userService := service.NewUsersService(userRepo, appState, refreshTokenRepo)
-	chripsService := service.NewChripsService(chirpRepo)
-	service := service.NewService()
-
-	restHandler := rest.NewRestHandler(appState, service, userService, chripsService)
-	mux := http.NewServeMux()
-	setupEndpoints(mux, restHandler, appState)
-
-	server := http.Server{
-		Addr:    ":8080",
-		Handler: mux,
-	}
-
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Could not start server: %v", err)
+	return stores{
+		chirps:        &chirpRepo,
+		users:         &userRepo,
+		refreshTokens: &refreshTokenRepo,
+		follows:       &followsRepo,
+		likes:         &likesRepo,
+		tags:          &tagsRepo,
+		reports:       &reportsRepo,
+		userTokens:    &userTokensRepo,
+		twoFactor:     &twoFactorRepo,
+		identities:    &identitiesRepo,
+		signingKeys:   &signingKeysRepo,
+		deniedTokens:  &deniedTokensRepo,
+		loginAttempts: &loginAttemptsRepo,
 	}
 }
 
@@ -62,24 +340,67 @@ func setupEndpoints(mux *http.ServeMux, handler rest.RestHandler, appState *stat
 	fsHandler := http.FileServer(pathToStatic)
 	fsHandlerWithMiddleware := appState.IncMetrics(fsHandler)
 	mux.Handle("/app/", http.StripPrefix("/app", fsHandlerWithMiddleware))
+	mux.HandleFunc("GET /.well-known/jwks.json", handler.HandleJWKS)
 
 	apiHandler := http.NewServeMux()
 	apiHandler.HandleFunc("GET /healthz", handler.HandleHealthCheck)
 	apiHandler.HandleFunc("POST /validate_chirp", handler.HandleValidateChirp)
 	apiHandler.HandleFunc("POST /users", handler.HandleCreateUser)
-	apiHandler.HandleFunc("POST /chirps", handler.HandleCreateChirp)
-	apiHandler.HandleFunc("GET /chirps", handler.HandleGetAllChirps)
-	apiHandler.HandleFunc("GET /chirps/{chirpID}", handler.HandleGetChirpByID)
+	apiHandler.HandleFunc("POST /chirps", handler.RequireAuth(handler.HandleCreateChirp))
+	apiHandler.HandleFunc("POST /chirps/{chirpID}/rechirp", handler.RequireAuth(handler.HandleRechirp))
+	apiHandler.HandleFunc("GET /chirps", handler.OptionalAuth(handler.HandleGetAllChirps))
+	apiHandler.HandleFunc("GET /chirps/search", handler.OptionalAuth(handler.HandleSearchChirps))
+	apiHandler.HandleFunc("GET /chirps/{chirpID}", handler.OptionalAuth(handler.HandleGetChirpByID))
+	apiHandler.HandleFunc("GET /chirps/{chirpID}/thread", handler.OptionalAuth(handler.HandleGetThread))
+	apiHandler.HandleFunc("POST /chirps/{chirpID}/like", handler.RequireAuth(handler.HandleLikeChirp))
+	apiHandler.HandleFunc("DELETE /chirps/{chirpID}/like", handler.RequireAuth(handler.HandleUnlikeChirp))
+	apiHandler.HandleFunc("POST /chirps/{chirpID}/report", handler.RequireAuth(handler.HandleReportChirp))
 	apiHandler.HandleFunc("POST /login", handler.HandleLogin)
-	apiHandler.HandleFunc("PUT /users", handler.HandleUpdateAccount)
-	apiHandler.HandleFunc("DELETE /chirps/{chirpID}", handler.HandleDeleteChirp)
+	apiHandler.HandleFunc("POST /login/mfa", handler.HandleMFALogin)
+	apiHandler.HandleFunc("GET /login/unlock", handler.HandleUnlockAccount)
+	apiHandler.HandleFunc("GET /auth/{provider}/login", handler.HandleOIDCLogin)
+	apiHandler.HandleFunc("GET /auth/{provider}/callback", handler.HandleOIDCCallback)
+	apiHandler.HandleFunc("POST /2fa/totp", handler.RequireAuth(handler.HandleEnrollTOTP))
+	apiHandler.HandleFunc("POST /2fa/totp/confirm", handler.RequireAuth(handler.HandleConfirmTOTP))
+	apiHandler.HandleFunc("POST /2fa/totp/disable", handler.RequireAuth(handler.HandleDisableTOTP))
+	apiHandler.HandleFunc("POST /password/forgot", handler.HandleForgotPassword)
+	apiHandler.HandleFunc("POST /password/reset", handler.HandleResetPassword)
+	apiHandler.HandleFunc("GET /verify", handler.HandleVerifyEmail)
+	apiHandler.HandleFunc("POST /verify/resend", handler.RequireAuth(handler.HandleResendVerification))
+	apiHandler.HandleFunc("PUT /users", handler.RequireAuth(handler.HandleUpdateAccount))
+	apiHandler.HandleFunc("GET /users/{username}", handler.HandleGetProfile)
+	apiHandler.HandleFunc("PUT /chirps/{chirpID}", handler.RequireAuth(handler.HandleEditChirp))
+	apiHandler.HandleFunc("GET /chirps/{chirpID}/revisions", handler.HandleGetRevisions)
+	apiHandler.HandleFunc("DELETE /chirps/{chirpID}", handler.RequireAuth(handler.HandleDeleteChirp))
 	apiHandler.HandleFunc("POST /polka/webhooks", handler.HandleUpgradeToRed)
 	apiHandler.HandleFunc("POST /refresh", handler.HandleRefresh)
 	apiHandler.HandleFunc("POST /revoke", handler.HandleRevoke)
+	apiHandler.HandleFunc("GET /sessions", handler.RequireAuth(handler.HandleListSessions))
+	apiHandler.HandleFunc("DELETE /sessions/{id}", handler.RequireAuth(handler.HandleRevokeSession))
+	apiHandler.HandleFunc("POST /sessions/revoke-all", handler.RequireAuth(handler.HandleRevokeAllSessions))
+	apiHandler.HandleFunc("POST /users/{userID}/follow", handler.RequireAuth(handler.HandleFollow))
+	apiHandler.HandleFunc("DELETE /users/{userID}/follow", handler.RequireAuth(handler.HandleUnfollow))
+	apiHandler.HandleFunc("GET /users/{userID}/followers", handler.HandleGetFollowers)
+	apiHandler.HandleFunc("GET /users/{userID}/following", handler.HandleGetFollowing)
+	apiHandler.HandleFunc("GET /users/{userID}/likes", handler.OptionalAuth(handler.HandleGetUserLikes))
+	apiHandler.HandleFunc("GET /users/{userID}/mentions", handler.OptionalAuth(handler.HandleGetMentions))
+	apiHandler.HandleFunc("POST /users/{userID}/report", handler.RequireAuth(handler.HandleReportUser))
+	apiHandler.HandleFunc("GET /hashtags/trending", handler.HandleTrendingHashtags)
+	apiHandler.HandleFunc("GET /hashtags/{tag}/chirps", handler.OptionalAuth(handler.HandleGetHashtagChirps))
+	apiHandler.HandleFunc("GET /timeline", handler.RequireAuth(handler.HandleTimeline))
 	mux.Handle("/api/", http.StripPrefix("/api", apiHandler))
 
 	adminHandler := http.NewServeMux()
-	adminHandler.HandleFunc("GET /metrics", handler.HandleViewMetrics)
-	adminHandler.HandleFunc("POST /reset", handler.HandleReset)
+	adminHandler.HandleFunc("GET /metrics", handler.RequirePermission(auth.PermViewMetrics, handler.HandleViewMetrics))
+	adminHandler.HandleFunc("POST /reset", handler.RequirePermission(auth.PermResetPlatform, handler.HandleReset))
+	adminHandler.HandleFunc("GET /reports", handler.RequirePermission(auth.PermModerate, handler.HandleListReports))
+	adminHandler.HandleFunc("GET /reports/{reportID}", handler.RequirePermission(auth.PermModerate, handler.HandleGetReport))
+	adminHandler.HandleFunc("POST /reports/{reportID}/claim", handler.RequirePermission(auth.PermModerate, handler.HandleClaimReport))
+	adminHandler.HandleFunc("POST /reports/{reportID}/resolve", handler.RequirePermission(auth.PermModerate, handler.HandleResolveReport))
+	adminHandler.HandleFunc("GET /users/{userID}/roles", handler.RequirePermission(auth.PermManageRoles, handler.HandleGetRoles))
+	adminHandler.HandleFunc("POST /users/{userID}/roles", handler.RequirePermission(auth.PermManageRoles, handler.HandleGrantRole))
+	adminHandler.HandleFunc("DELETE /users/{userID}/roles/{role}", handler.RequirePermission(auth.PermManageRoles, handler.HandleRevokeRole))
+	adminHandler.HandleFunc("GET /login-attempts", handler.RequirePermission(auth.PermViewAudit, handler.HandleListLoginAttempts))
+	adminHandler.HandleFunc("POST /keys/rotate", handler.RequirePermission(auth.PermManageKeys, handler.HandleRotateKeys))
 	mux.Handle("/admin/", http.StripPrefix("/admin", adminHandler))
 }
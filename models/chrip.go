@@ -7,9 +7,56 @@ import (
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ReplyToID *uuid.UUID `json:"reply_to_id,omitempty"`
+	// DeletedAt is set on tombstones: chirps that were deleted while they
+	// still had replies. Their body is cleared but the row keeps the thread
+	// together.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// HiddenAt is set when a moderator hid the chirp. Hidden chirps are
+	// left out of listings and only show up as tombstones in threads.
+	HiddenAt *time.Time `json:"-"`
+	// RepostOfID is set on rechirps. A rechirp without a body is a plain
+	// repost, one with a body is a quote.
+	RepostOfID *uuid.UUID `json:"repost_of_id,omitempty"`
+	// RepostOf embeds the original in responses. RepostUnavailable is set
+	// instead when the original has been deleted.
+	RepostOf          *Chirp `json:"repost_of,omitempty"`
+	RepostUnavailable bool   `json:"repost_unavailable,omitempty"`
+	LikeCount         int    `json:"like_count"`
+	// LikedByMe is only set when the request was authenticated.
+	LikedByMe *bool `json:"liked_by_me,omitempty"`
+}
+
+// ChirpRevision is a body a chirp had before it was edited.
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+type ChirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type ThreadNode struct {
+	Chirp
+	Replies []ThreadNode `json:"replies,omitempty"`
+	// HasMoreReplies marks nodes at the depth limit whose replies were not
+	// included. Fetch their own thread to read further.
+	HasMoreReplies bool `json:"has_more_replies,omitempty"`
+}
+
+type ChirpThread struct {
+	Chirp      Chirp        `json:"chirp"`
+	Ancestors  []Chirp      `json:"ancestors"`
+	Replies    []ThreadNode `json:"replies"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// DeniedToken revokes access tokens before they expire. ID is a token's jti
+// or a session id, which denies every token of the session.
+type DeniedToken struct {
+	ID        string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
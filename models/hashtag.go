@@ -0,0 +1,11 @@
+package models
+
+type TrendingHashtag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type TrendingHashtags struct {
+	Window   string            `json:"window"`
+	Hashtags []TrendingHashtag `json:"hashtags"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a login attempt failed.
+const (
+	LoginFailureUnknownEmail  = "unknown_email"
+	LoginFailureWrongPassword = "wrong_password"
+	LoginFailureWrongMFACode  = "wrong_mfa_code"
+	// LoginFailureThrottled is an attempt refused without checking the
+	// password because the account or address was backing off.
+	LoginFailureThrottled = "throttled"
+)
+
+// LoginAttempt is an entry in the audit trail of failed logins. UserID is
+// nil when the email belongs to no account.
+type LoginAttempt struct {
+	ID        uuid.UUID  `json:"id"`
+	Email     string     `json:"email"`
+	UserID    *uuid.UUID `json:"user_id"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LoginAttemptFilter narrows a listing of login attempts. Empty fields
+// match everything.
+type LoginAttemptFilter struct {
+	UserID string
+	Email  string
+	IP     string
+}
+
+type LoginAttemptPage struct {
+	LoginAttempts []LoginAttempt `json:"login_attempts"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// LoginThrottle counts the recent failed logins for an account or a client
+// address, named by Key.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+}
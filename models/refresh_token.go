@@ -14,4 +14,12 @@ type RefreshToken struct {
 	UserID    uuid.UUID    `json:"user_id"`
 	ExpiresAt time.Time    `json:"expires_at"`
 	RevokedAt sql.NullTime `json:"revoked_at"`
+	// FamilyID is shared by all tokens descending from the same login.
+	FamilyID    uuid.UUID      `json:"family_id"`
+	ParentToken sql.NullString `json:"-"`
+	// RotatedAt is set once the token was exchanged for its successor.
+	RotatedAt sql.NullTime `json:"rotated_at"`
+	// UserAgent and IP are those of the client the token was issued to.
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
 }
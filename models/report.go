@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReportTargetChirp = "chirp"
+	ReportTargetUser  = "user"
+
+	ReportStatusOpen     = "open"
+	ReportStatusClaimed  = "claimed"
+	ReportStatusResolved = "resolved"
+
+	ResolutionHideChirp   = "hide_chirp"
+	ResolutionSuspendUser = "suspend_user"
+	ResolutionDismiss     = "dismiss"
+
+	// DecisionClaim is recorded when a moderator takes a report. The other
+	// decisions use the resolution names.
+	DecisionClaim = "claim"
+)
+
+type Report struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// ReporterID is nil once the reporter's account is deleted.
+	ReporterID *uuid.UUID `json:"reporter_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   uuid.UUID  `json:"target_id"`
+	// TargetUserID is the reported user, or the author of the reported
+	// chirp.
+	TargetUserID uuid.UUID  `json:"target_user_id"`
+	Reason       string     `json:"reason"`
+	Details      string     `json:"details,omitempty"`
+	Status       string     `json:"status"`
+	ClaimedBy    *uuid.UUID `json:"claimed_by,omitempty"`
+	ClaimedAt    *time.Time `json:"claimed_at,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	Resolution   string     `json:"resolution,omitempty"`
+	Decisions    []Decision `json:"decisions,omitempty"`
+}
+
+// Decision is one step a moderator took on a report.
+type Decision struct {
+	ID          uuid.UUID  `json:"id"`
+	ReportID    uuid.UUID  `json:"report_id"`
+	ModeratorID *uuid.UUID `json:"moderator_id"`
+	Action      string     `json:"action"`
+	Note        string     `json:"note,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ReportPage struct {
+	Reports    []Report `json:"reports"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
@@ -1,6 +1,86 @@
 package models
 
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Error codes clients can rely on. Errors without an explicit Code get the
+// one matching their status code.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeInvalidToken     = "invalid_token"
+	CodeLoginFailed      = "invalid_credentials"
+	CodeTooManyAttempts  = "too_many_attempts"
+	CodeInvalidUserToken = "invalid_user_token"
+	CodeInvalidMFACode   = "invalid_mfa_code"
+	CodeTokenReused      = "refresh_token_reused"
+	CodeForbidden        = "forbidden"
+	CodeEmailNotVerified = "email_not_verified"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal_error"
+)
+
 type ResponseErr struct {
-	Error      string `json:"error,omitempty"`
-	StatusCode int    `json:"status_code,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	StatusCode int          `json:"status_code,omitempty"`
+	Code       string       `json:"code,omitempty"`
+	Fields     []FieldError `json:"fields,omitempty"`
+	// RetryAfter tells clients of a 429 or 503 how long to wait.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FieldError explains why one request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewFieldError reports a single invalid request field.
+func NewFieldError(field, message string) *ResponseErr {
+	return &ResponseErr{
+		Error:      message,
+		StatusCode: http.StatusBadRequest,
+		Code:       CodeValidationFailed,
+		Fields:     []FieldError{{Field: field, Message: message}},
+	}
+}
+
+// ValidateID rejects value for field unless it is a well-formed id, so
+// malformed ids never reach the stores.
+func ValidateID(field, value string) *ResponseErr {
+	if _, err := uuid.Parse(value); err != nil {
+		return NewFieldError(field, field+" must be a valid id")
+	}
+	return nil
+}
+
+// ErrorCode returns Code, falling back to a code derived from StatusCode.
+func (e *ResponseErr) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	}
+	if e.StatusCode >= 500 || e.StatusCode == 0 {
+		return CodeInternal
+	}
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(e.StatusCode), " ", "_"))
 }
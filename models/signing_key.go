@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SigningKey is a key Chirpy signs its tokens with. Private holds the PKCS #8
+// encoding of asymmetric keys and the secret itself for HMAC keys.
+type SigningKey struct {
+	ID        string     `json:"kid"`
+	Algorithm string     `json:"algorithm"`
+	Private   []byte     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTP is a user's authenticator app enrollment. It only protects logins
+// once EnabledAt is set by confirming a first code.
+type TOTP struct {
+	UserID    uuid.UUID
+	Secret    string
+	CreatedAt time.Time
+	EnabledAt *time.Time
+	// LastStep is the last time step a code was accepted for, so codes
+	// cannot be replayed.
+	LastStep int64
+}
+
+// TOTPEnrollment is returned when a user starts setting up 2FA.
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are shown once, when 2FA is confirmed.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge is returned by login instead of tokens when the account has
+// 2FA enabled. MFAToken is exchanged for tokens at POST /api/login/mfa.
+type MFAChallenge struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -6,13 +6,47 @@ import (
 	"github.com/google/uuid"
 )
 
+// Every account has RoleUser. The others are granted by an admin.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	Password     string    `json:"-"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
-	Token        string    `json:"token,omitempty"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Email     string    `json:"email"`
+	Password  string    `json:"-"`
+	Profile
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	IsChirpyRed     bool       `json:"is_chirpy_red"`
+	Roles           []string   `json:"roles"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	Token           string     `json:"token,omitempty"`
+	RefreshToken    string     `json:"refresh_token,omitempty"`
+}
+
+// Profile holds the user fields anyone may see.
+type Profile struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+// PublicProfile is what GET /api/users/{username} returns. It leaves out the
+// email and tokens.
+type PublicProfile struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Profile
+	IsChirpyRed bool `json:"is_chirpy_red"`
+}
+
+// UserRoles is what the admin role endpoints return.
+type UserRoles struct {
+	UserID uuid.UUID `json:"user_id"`
+	Roles  []string  `json:"roles"`
 }
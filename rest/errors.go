@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// ErrorResponse is the body of every API error.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code      string              `json:"code"`
+	Message   string              `json:"message"`
+	Details   []models.FieldError `json:"details,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+// clientRequestID limits which caller supplied request ids are echoed back.
+var clientRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// WithRequestID tags every request with an id, reusing a well-formed
+// X-Request-ID from the caller. The id is returned in the same header and in
+// error bodies so reports can be matched with the logs.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !clientRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestID(r *http.Request) string {
+	requestID, _ := r.Context().Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// writeResponseErr sends respErr in the error envelope. Server errors are
+// logged in full and replaced with a generic message so database errors do
+// not reach clients.
+func writeResponseErr(w http.ResponseWriter, r *http.Request, respErr *models.ResponseErr) {
+	statusCode := respErr.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+
+	body := ErrorBody{
+		Code:      respErr.ErrorCode(),
+		Message:   respErr.Error,
+		Details:   respErr.Fields,
+		RequestID: requestID(r),
+	}
+	if statusCode >= http.StatusInternalServerError {
+		log.Printf("request %s: %s %s: %s", body.RequestID, r.Method, r.URL.Path, respErr.Error)
+		body.Message = "Internal server error"
+		body.Details = nil
+	}
+
+	respJson, err := json.Marshal(ErrorResponse{Error: body})
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if respErr.RetryAfter > 0 {
+		seconds := (respErr.RetryAfter + time.Second - 1) / time.Second
+		w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(respJson)
+}
+
+// pathID returns the path value name if it is a well-formed id. Otherwise
+// it writes a 400 for field and returns false.
+func pathID(w http.ResponseWriter, r *http.Request, name, field string) (string, bool) {
+	id := r.PathValue(name)
+	if respErr := models.ValidateID(field, id); respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return "", false
+	}
+	return id, true
+}
+
+// writeError sends err with the given status code in the error envelope.
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	writeResponseErr(w, r, &models.ResponseErr{
+		Error:      err.Error(),
+		StatusCode: statusCode,
+	})
+}
@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+func TestMalformedPathIDIsRejected(t *testing.T) {
+	handler := &RestHandler{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", handler.HandleGetChirpByID)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/chirps/not-a-uuid", nil))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 but got %d", recorder.Code)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatalf("Expected an error envelope but got error: %v", err)
+	}
+	if body.Error.Code != models.CodeValidationFailed {
+		t.Errorf("Expected code %q but got %q", models.CodeValidationFailed, body.Error.Code)
+	}
+	if len(body.Error.Details) != 1 || body.Error.Details[0].Field != "chirp_id" {
+		t.Errorf("Expected a chirp_id field error but got %v", body.Error.Details)
+	}
+}
@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+)
+
+func (h *RestHandler) HandleFollow(w http.ResponseWriter, r *http.Request) {
+	followerID := principal(r).UserID
+
+	followeeID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	respErr := h.followService.Follow(r.Context(), followerID.String(), followeeID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *RestHandler) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	followerID := principal(r).UserID
+
+	followeeID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	respErr := h.followService.Unfollow(r.Context(), followerID.String(), followeeID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *RestHandler) HandleGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	follows, respErr := h.followService.GetFollowers(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(follows)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	follows, respErr := h.followService.GetFollowing(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(follows)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	chirps, respErr := h.followService.Timeline(r.Context(), userID.String(), r.URL.Query().Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(chirps)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
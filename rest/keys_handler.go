@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HandleJWKS publishes the public keys access tokens can be verified with.
+func (h *RestHandler) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	respJson, err := json.Marshal(h.appState.Keys.JWKS())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Verifiers refetch the set when they see an unknown kid, so a short
+	// cache only delays them by as much after a rotation.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleRotateKeys(w http.ResponseWriter, r *http.Request) {
+	key, respErr := h.signingKeysService.Rotate(r.Context())
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(key)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+	w.Write(respJson)
+}
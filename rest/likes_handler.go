@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+)
+
+func (h *RestHandler) HandleLikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	respErr := h.likeService.Like(r.Context(), userID.String(), chirpID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *RestHandler) HandleUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	respErr := h.likeService.Unlike(r.Context(), userID.String(), chirpID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *RestHandler) HandleGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	viewerID := optionalUserID(r)
+
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	chirps, respErr := h.likeService.GetLikedChirps(r.Context(), viewerID, userID, r.URL.Query().Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(chirps)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
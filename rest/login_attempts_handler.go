@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// HandleUnlockAccount lifts a login lockout with the token from an unlock
+// email.
+func (h *RestHandler) HandleUnlockAccount(w http.ResponseWriter, r *http.Request) {
+	respErr := h.loginThrottleService.Unlock(r.Context(), r.URL.Query().Get("token"))
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// HandleListLoginAttempts pages through failed logins, optionally narrowed
+// by user_id, email or ip.
+func (h *RestHandler) HandleListLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := models.LoginAttemptFilter{
+		UserID: query.Get("user_id"),
+		Email:  query.Get("email"),
+		IP:     query.Get("ip"),
+	}
+	page, respErr := h.loginThrottleService.ListAttempts(r.Context(), filter, query.Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(page)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
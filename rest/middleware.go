@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+const authRealm = "chirpy"
+
+// RequireAuth rejects requests without a valid access token and stores the
+// caller in the request context for next.
+func (h *RestHandler) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			writeAuthError(w, r, http.StatusUnauthorized, models.CodeUnauthorized, "Missing access token")
+			return
+		}
+
+		principal, err := h.authenticate(r)
+		if err != nil {
+			writeAuthError(w, r, http.StatusUnauthorized, models.CodeInvalidToken, err.Error())
+			return
+		}
+
+		next(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// OptionalAuth lets anonymous requests through. A token that is present but
+// invalid is still rejected.
+func (h *RestHandler) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next(w, r)
+			return
+		}
+
+		h.RequireAuth(next)(w, r)
+	}
+}
+
+// RequirePermission only lets requests through whose access token carries a
+// role granting perm. Roles are read from the token, so a grant or revoke
+// applies once the user refreshes it.
+func (h *RestHandler) RequirePermission(perm auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return h.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.PrincipalFrom(r.Context()).HasPermission(perm) {
+			writeAuthError(w, r, http.StatusForbidden, models.CodeForbidden, "Insufficient permissions")
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+func (h *RestHandler) authenticate(r *http.Request) (*auth.Principal, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := auth.ParseJWT(token, h.appState.Keys, h.appState.Denylist)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewPrincipal(claims)
+}
+
+// principal returns the caller of a route registered with RequireAuth.
+func principal(r *http.Request) *auth.Principal {
+	return auth.PrincipalFrom(r.Context())
+}
+
+// optionalUserID returns the caller's id on OptionalAuth routes, or an
+// empty string for anonymous requests.
+func optionalUserID(r *http.Request) string {
+	if caller := auth.PrincipalFrom(r.Context()); caller != nil {
+		return caller.UserID.String()
+	}
+	return ""
+}
+
+// writeAuthError answers with the error envelope and the WWW-Authenticate
+// challenge from RFC 6750.
+func writeAuthError(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	challenge := fmt.Sprintf("Bearer realm=%q", authRealm)
+	switch code {
+	case models.CodeInvalidToken:
+		challenge += `, error="invalid_token"`
+	case models.CodeForbidden:
+		challenge += `, error="insufficient_scope"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+
+	writeResponseErr(w, r, &models.ResponseErr{
+		Error:      message,
+		StatusCode: statusCode,
+		Code:       code,
+	})
+}
@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/service"
+)
+
+// oidcStateCookie carries the signed login state from HandleOIDCLogin to
+// HandleOIDCCallback.
+const oidcStateCookie = "chirpy_oidc"
+
+func (h *RestHandler) HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, stateToken, respErr := h.oidcService.BeginLogin(r.Context(), r.PathValue("provider"))
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	setOIDCStateCookie(w, r, stateToken, service.OIDCLoginTTL)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h *RestHandler) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	// The state cookie is single use whatever the outcome.
+	setOIDCStateCookie(w, r, "", -1)
+
+	if query.Get("error") != "" {
+		writeResponseErr(w, r, &models.ResponseErr{
+			Error:      "Sign in was cancelled or denied at the provider",
+			StatusCode: http.StatusUnauthorized,
+		})
+		return
+	}
+
+	var stateToken string
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		stateToken = cookie.Value
+	}
+
+	user, challenge, respErr := h.oidcService.CompleteLogin(r.Context(), r.PathValue("provider"), query.Get("code"), query.Get("state"), stateToken, clientInfo(r))
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	var response any = user
+	if challenge != nil {
+		response = challenge
+	}
+	respJson, err := json.Marshal(response)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+// setOIDCStateCookie stores the login state, or clears it when maxAge is
+// negative. SameSite=Lax still sends it on the provider's redirect back.
+func setOIDCStateCookie(w http.ResponseWriter, r *http.Request, value string, maxAge time.Duration) {
+	cookie := &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/api/auth/",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type ForgotPasswordDto struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordDto struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (h *RestHandler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	data := ForgotPasswordDto{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	respErr := h.passwordResetService.Forgot(r.Context(), data.Email)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(202)
+}
+
+func (h *RestHandler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	data := ResetPasswordDto{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	respErr := h.passwordResetService.Reset(r.Context(), data.Token, data.Password)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
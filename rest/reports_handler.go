@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type ReportDto struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+type ResolveReportDto struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+func (h *RestHandler) HandleReportChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	h.handleReport(w, r, h.reportService.ReportChirp, chirpID)
+}
+
+func (h *RestHandler) HandleReportUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	h.handleReport(w, r, h.reportService.ReportUser, userID)
+}
+
+func (h *RestHandler) handleReport(
+	w http.ResponseWriter,
+	r *http.Request,
+	report func(ctx context.Context, reporterID, targetID, reason, details string) (*models.Report, *models.ResponseErr),
+	targetID string,
+) {
+	userID := principal(r).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	data := ReportDto{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	created, respErr := report(r.Context(), userID.String(), targetID, data.Reason, data.Details)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(created)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleListReports(w http.ResponseWriter, r *http.Request) {
+
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	reports, respErr := h.reportService.ListReports(r.Context(), status, r.URL.Query().Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(reports)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleGetReport(w http.ResponseWriter, r *http.Request) {
+
+	reportID, ok := pathID(w, r, "reportID", "report_id")
+	if !ok {
+		return
+	}
+	report, respErr := h.reportService.GetReport(r.Context(), reportID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(report)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleClaimReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID := principal(r).UserID
+
+	reportID, ok := pathID(w, r, "reportID", "report_id")
+	if !ok {
+		return
+	}
+	report, respErr := h.reportService.Claim(r.Context(), moderatorID.String(), reportID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(report)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleResolveReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID := principal(r).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	data := ResolveReportDto{}
+	err := decoder.Decode(&data)
+	if err != nil && err != io.EOF {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	reportID, ok := pathID(w, r, "reportID", "report_id")
+	if !ok {
+		return
+	}
+	report, respErr := h.reportService.Resolve(r.Context(), moderatorID.String(), reportID, data.Action, data.Note)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(report)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
@@ -3,19 +3,30 @@ package rest
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
 	"github.com/karaMuha/go-chirpy/models"
 	"github.com/karaMuha/go-chirpy/service"
 	"github.com/karaMuha/go-chirpy/state"
 )
 
 type RestHandler struct {
-	appState     *state.AppState
-	service      service.Service
-	userService  service.UsersService
-	chirpService service.ChirpsService
+	appState             *state.AppState
+	service              service.Service
+	userService          service.UsersService
+	chirpService         service.ChirpsService
+	followService        service.FollowsService
+	likeService          service.LikesService
+	reportService        service.ReportsService
+	passwordResetService service.PasswordResetService
+	verificationService  service.EmailVerificationService
+	oidcService          service.OIDCService
+	signingKeysService   service.SigningKeysService
+	loginThrottleService service.LoginThrottleService
 }
 
 func NewRestHandler(
@@ -23,12 +34,28 @@ func NewRestHandler(
 	service service.Service,
 	userService service.UsersService,
 	chirpService service.ChirpsService,
+	followService service.FollowsService,
+	likeService service.LikesService,
+	reportService service.ReportsService,
+	passwordResetService service.PasswordResetService,
+	verificationService service.EmailVerificationService,
+	oidcService service.OIDCService,
+	signingKeysService service.SigningKeysService,
+	loginThrottleService service.LoginThrottleService,
 ) RestHandler {
 	return RestHandler{
-		appState:     appState,
-		service:      service,
-		userService:  userService,
-		chirpService: chirpService,
+		appState:             appState,
+		service:              service,
+		userService:          userService,
+		chirpService:         chirpService,
+		followService:        followService,
+		likeService:          likeService,
+		reportService:        reportService,
+		passwordResetService: passwordResetService,
+		verificationService:  verificationService,
+		oidcService:          oidcService,
+		signingKeysService:   signingKeysService,
+		loginThrottleService: loginThrottleService,
 	}
 }
 
@@ -58,13 +85,16 @@ func (h *RestHandler) HandleReset(w http.ResponseWriter, r *http.Request) {
 	h.appState.ResetFileServerHitsCound()
 
 	if h.appState.Platform != "dev" {
-		http.Error(w, "Not allowed outside of dev env", http.StatusForbidden)
+		writeResponseErr(w, r, &models.ResponseErr{
+			Error:      "Not allowed outside of dev env",
+			StatusCode: http.StatusForbidden,
+		})
 		return
 	}
 
 	respErr := h.userService.ResetUsers(r.Context())
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 }
@@ -74,19 +104,19 @@ func (h *RestHandler) HandleValidateChirp(w http.ResponseWriter, r *http.Request
 	chirp := models.Chirp{}
 	err := decorder.Decode(&chirp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	response, respErr := h.service.ValidateChirp(chirp)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
 	respJson, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -96,8 +126,22 @@ func (h *RestHandler) HandleValidateChirp(w http.ResponseWriter, r *http.Request
 }
 
 type CreateUserDto struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+// UpdateAccountDto leaves fields that are omitted unchanged.
+type UpdateAccountDto struct {
+	Email       string  `json:"email"`
+	Password    string  `json:"password"`
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatar_url"`
 }
 
 func (h *RestHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
@@ -105,24 +149,25 @@ func (h *RestHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	data := CreateUserDto{}
 	err := decoder.Decode(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(data.Password)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	profile := models.Profile{
+		Username:    data.Username,
+		DisplayName: data.DisplayName,
+		Bio:         data.Bio,
+		AvatarURL:   data.AvatarURL,
 	}
-	user, respErr := h.userService.CreateUser(r.Context(), data.Email, hashedPassword)
+	user, respErr := h.userService.CreateUser(r.Context(), data.Email, data.Password, profile)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
 	respJson, err := json.Marshal(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -132,40 +177,154 @@ func (h *RestHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 type CreateChirpsDto struct {
-	Body string `json:"body"`
+	Body      string `json:"body"`
+	ReplyToID string `json:"reply_to_id"`
 }
 
 func (h *RestHandler) HandleCreateChirp(w http.ResponseWriter, r *http.Request) {
-	headers := r.Header
-	token, err := auth.GetBearerToken(headers)
+	userID := principal(r).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	data := CreateChirpsDto{}
+	err := decoder.Decode(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if data.ReplyToID != "" {
+		if respErr := models.ValidateID("reply_to_id", data.ReplyToID); respErr != nil {
+			writeResponseErr(w, r, respErr)
+			return
+		}
+	}
+
+	response, respErr := h.service.ValidateChirp(models.Chirp{Body: data.Body})
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	chrip, respErr := h.chirpService.CreateChrip(r.Context(), response.CleanedBody, userID.String(), data.ReplyToID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, h.appState.Secret)
+	respJson, err := json.Marshal(chrip)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+	w.Write(respJson)
+}
+
+type EditChirpDto struct {
+	Body string `json:"body"`
+}
+
+func (h *RestHandler) HandleEditChirp(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
 	decoder := json.NewDecoder(r.Body)
-	data := CreateChirpsDto{}
-	err = decoder.Decode(&data)
+	data := EditChirpDto{}
+	err := decoder.Decode(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	chrip, respErr := h.chirpService.CreateChrip(r.Context(), data.Body, userID.String())
+	response, respErr := h.service.ValidateChirp(models.Chirp{Body: data.Body})
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
-	respJson, err := json.Marshal(chrip)
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	chirp, respErr := h.chirpService.Edit(r.Context(), userID.String(), chirpID, response.CleanedBody)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(chirp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleGetRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	revisions, respErr := h.chirpService.GetRevisions(r.Context(), chirpID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(revisions)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+type RechirpDto struct {
+	Body string `json:"body"`
+}
+
+// HandleRechirp reposts a chirp. The request body is optional, a body with
+// commentary turns the rechirp into a quote. Only the commentary counts
+// towards the chirp length limit.
+func (h *RestHandler) HandleRechirp(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	data := RechirpDto{}
+	err := decoder.Decode(&data)
+	if err != nil && err != io.EOF {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if data.Body != "" {
+		response, respErr := h.service.ValidateChirp(models.Chirp{Body: data.Body})
+		if respErr != nil {
+			writeResponseErr(w, r, respErr)
+			return
+		}
+		data.Body = response.CleanedBody
+	}
+
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	chirp, respErr := h.chirpService.Rechirp(r.Context(), userID.String(), chirpID, data.Body)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(chirp)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -175,21 +334,63 @@ func (h *RestHandler) HandleCreateChirp(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *RestHandler) HandleGetAllChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID := optionalUserID(r)
+
 	sorting := r.URL.Query().Get("sort")
 	if sorting == "" {
 		sorting = "ASC"
 	}
 	authorID := r.URL.Query().Get("author_id")
+	if authorID != "" {
+		if respErr := models.ValidateID("author_id", authorID); respErr != nil {
+			writeResponseErr(w, r, respErr)
+			return
+		}
+	}
+	cursor := r.URL.Query().Get("cursor")
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	chirps, respErr := h.chirpService.GetAll(r.Context(), viewerID, authorID, sorting, cursor, limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(chirps)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID := optionalUserID(r)
+
+	text := r.URL.Query().Get("q")
+	cursor := r.URL.Query().Get("cursor")
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
 
-	chirps, respErr := h.chirpService.GetAll(r.Context(), authorID, sorting)
+	chirps, respErr := h.chirpService.Search(r.Context(), viewerID, text, cursor, limit)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
 	respJson, err := json.Marshal(chirps)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -199,16 +400,21 @@ func (h *RestHandler) HandleGetAllChirps(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *RestHandler) HandleGetChirpByID(w http.ResponseWriter, r *http.Request) {
-	chirpID := r.PathValue("chirpID")
-	chirp, respErr := h.chirpService.GetByID(r.Context(), chirpID)
+	viewerID := optionalUserID(r)
+
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	chirp, respErr := h.chirpService.GetByID(r.Context(), viewerID, chirpID)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
 	respJson, err := json.Marshal(chirp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -217,29 +423,38 @@ func (h *RestHandler) HandleGetChirpByID(w http.ResponseWriter, r *http.Request)
 	w.Write(respJson)
 }
 
-type LoginDto struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
+func (h *RestHandler) HandleGetThread(w http.ResponseWriter, r *http.Request) {
+	viewerID := optionalUserID(r)
 
-func (h *RestHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
-	decoder := json.NewDecoder(r.Body)
-	data := LoginDto{}
-	err := decoder.Decode(&data)
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	user, respErr := h.userService.Login(r.Context(), data.Email, data.Password, 3600)
+	depth := service.DefaultThreadDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		depth, err = strconv.Atoi(value)
+		if err != nil || depth < 1 {
+			writeResponseErr(w, r, models.NewFieldError("depth", "depth must be a positive integer"))
+			return
+		}
+		depth = min(depth, service.MaxThreadDepth)
+	}
+
+	thread, respErr := h.chirpService.GetThread(r.Context(), viewerID, chirpID, r.URL.Query().Get("cursor"), limit, depth)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
-	respJson, err := json.Marshal(user)
+	respJson, err := json.Marshal(thread)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -248,37 +463,52 @@ func (h *RestHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	w.Write(respJson)
 }
 
-func (h *RestHandler) HandleUpdateAccount(w http.ResponseWriter, r *http.Request) {
-	headers := r.Header
-	token, err := auth.GetBearerToken(headers)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+type LoginDto struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (h *RestHandler) HandleGetProfile(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	profile, respErr := h.userService.GetProfile(r.Context(), username)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, h.appState.Secret)
+	respJson, err := json.Marshal(profile)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
-	var data CreateUserDto
-	err = decoder.Decode(&data)
+	data := LoginDto{}
+	err := decoder.Decode(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	updatedUser, respErr := h.userService.UpdateAccount(r.Context(), userID.String(), data.Email, data.Password)
+	user, challenge, respErr := h.userService.Login(r.Context(), data.Email, data.Password, 3600, clientInfo(r))
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
-	respJson, err := json.Marshal(updatedUser)
+	var response any = user
+	if challenge != nil {
+		response = challenge
+	}
+	respJson, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -287,24 +517,50 @@ func (h *RestHandler) HandleUpdateAccount(w http.ResponseWriter, r *http.Request
 	w.Write(respJson)
 }
 
-func (h *RestHandler) HandleDeleteChirp(w http.ResponseWriter, r *http.Request) {
-	headers := r.Header
-	token, err := auth.GetBearerToken(headers)
+func (h *RestHandler) HandleUpdateAccount(w http.ResponseWriter, r *http.Request) {
+	caller := principal(r)
+
+	decoder := json.NewDecoder(r.Body)
+	var data UpdateAccountDto
+	err := decoder.Decode(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, h.appState.Secret)
+	update := service.ProfileUpdate{
+		Username:    data.Username,
+		DisplayName: data.DisplayName,
+		Bio:         data.Bio,
+		AvatarURL:   data.AvatarURL,
+	}
+	updatedUser, respErr := h.userService.UpdateAccount(r.Context(), caller.UserID.String(), caller.SessionID.String(), data.Email, data.Password, update)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(updatedUser)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
-	chirpID := r.PathValue("chirpID")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleDeleteChirp(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	chirpID, ok := pathID(w, r, "chirpID", "chirp_id")
+	if !ok {
+		return
+	}
 	respErr := h.chirpService.Delete(r.Context(), userID.String(), chirpID)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
@@ -321,11 +577,14 @@ type WebhookEvent struct {
 func (h *RestHandler) HandleUpgradeToRed(w http.ResponseWriter, r *http.Request) {
 	key, err := auth.GetAPIKey(r.Header)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusUnauthorized, err)
 		return
 	}
 	if key != h.appState.PolkaKey {
-		http.Error(w, "Key does not match", http.StatusUnauthorized)
+		writeResponseErr(w, r, &models.ResponseErr{
+			Error:      "Key does not match",
+			StatusCode: http.StatusUnauthorized,
+		})
 		return
 	}
 
@@ -333,7 +592,8 @@ func (h *RestHandler) HandleUpgradeToRed(w http.ResponseWriter, r *http.Request)
 	var event WebhookEvent
 	err = decoder.Decode(&event)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, err)
+		return
 	}
 
 	if event.Event != "user.upgraded" {
@@ -341,9 +601,14 @@ func (h *RestHandler) HandleUpgradeToRed(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if respErr := models.ValidateID("user_id", event.Data.UserID); respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
 	respErr := h.userService.UpgradeToRed(r.Context(), event.Data.UserID)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
@@ -351,29 +616,31 @@ func (h *RestHandler) HandleUpgradeToRed(w http.ResponseWriter, r *http.Request)
 }
 
 type Token struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 func (h *RestHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 	headers := r.Header
 	token, err := auth.GetBearerToken(headers)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
-	newJWT, respErr := h.userService.RefreshToken(r.Context(), token)
+	newJWT, refreshToken, respErr := h.userService.RefreshToken(r.Context(), token, clientInfo(r))
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
 	response := Token{
-		Token: newJWT,
+		Token:        newJWT,
+		RefreshToken: refreshToken,
 	}
 	respJson, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -386,13 +653,13 @@ func (h *RestHandler) HandleRevoke(w http.ResponseWriter, r *http.Request) {
 	headers := r.Header
 	token, err := auth.GetBearerToken(headers)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	respErr := h.userService.RevokeToken(r.Context(), token)
 	if respErr != nil {
-		http.Error(w, respErr.Error, respErr.StatusCode)
+		writeResponseErr(w, r, respErr)
 		return
 	}
 
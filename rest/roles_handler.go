@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type GrantRoleDto struct {
+	Role string `json:"role"`
+}
+
+func (h *RestHandler) HandleGetRoles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	roles, respErr := h.userService.GetRoles(r.Context(), userID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(roles)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleGrantRole(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	data := GrantRoleDto{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	roles, respErr := h.userService.GrantRole(r.Context(), userID, data.Role)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(roles)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleRevokeRole(w http.ResponseWriter, r *http.Request) {
+	adminID := principal(r).UserID
+
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	roles, respErr := h.userService.RevokeRole(r.Context(), adminID.String(), userID, r.PathValue("role"))
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(roles)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// maxUserAgentLength caps what is stored from the User-Agent header.
+const maxUserAgentLength = 512
+
+func (h *RestHandler) HandleListSessions(w http.ResponseWriter, r *http.Request) {
+	caller := principal(r)
+
+	sessions, respErr := h.userService.ListSessions(r.Context(), caller.UserID.String(), caller.SessionID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(sessions)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	sessionID, ok := pathID(w, r, "id", "id")
+	if !ok {
+		return
+	}
+	respErr := h.userService.RevokeSession(r.Context(), userID.String(), sessionID)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *RestHandler) HandleRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	respErr := h.userService.RevokeAllSessions(r.Context(), userID.String())
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// clientInfo describes the client of r for the sessions list.
+func clientInfo(r *http.Request) models.ClientInfo {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return models.ClientInfo{
+		UserAgent: userAgent,
+		IP:        ip,
+	}
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/service"
+)
+
+func (h *RestHandler) HandleGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID := optionalUserID(r)
+
+	tag := r.PathValue("tag")
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	chirps, respErr := h.chirpService.GetByHashtag(r.Context(), viewerID, tag, r.URL.Query().Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(chirps)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleGetMentions(w http.ResponseWriter, r *http.Request) {
+	viewerID := optionalUserID(r)
+
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	chirps, respErr := h.chirpService.GetMentions(r.Context(), viewerID, userID, r.URL.Query().Get("cursor"), limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(chirps)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+// HandleTrendingHashtags lists the most used hashtags over a sliding window
+// given as a Go duration, e.g. ?window=6h.
+func (h *RestHandler) HandleTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	window := service.DefaultTrendingWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			writeResponseErr(w, r, models.NewFieldError("window", "window must be a positive duration like 24h"))
+			return
+		}
+		window = min(parsed, service.MaxTrendingWindow)
+	}
+
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	trending, respErr := h.chirpService.TrendingHashtags(r.Context(), window, limit)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(trending)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
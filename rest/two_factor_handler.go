@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type TOTPCodeDto struct {
+	Code string `json:"code"`
+}
+
+type MFALoginDto struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+func (h *RestHandler) HandleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	enrollment, respErr := h.userService.EnrollTOTP(r.Context(), userID.String())
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(enrollment)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	data := TOTPCodeDto{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	codes, respErr := h.userService.ConfirmTOTP(r.Context(), userID.String(), data.Code)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(codes)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
+
+func (h *RestHandler) HandleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	decoder := json.NewDecoder(r.Body)
+	data := TOTPCodeDto{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	respErr := h.userService.DisableTOTP(r.Context(), userID.String(), data.Code)
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *RestHandler) HandleMFALogin(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	data := MFALoginDto{}
+	err := decoder.Decode(&data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	user, respErr := h.userService.CompleteMFALogin(r.Context(), data.MFAToken, data.Code, clientInfo(r))
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	respJson, err := json.Marshal(user)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(respJson)
+}
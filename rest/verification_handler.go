@@ -0,0 +1,27 @@
+package rest
+
+import (
+	"net/http"
+)
+
+func (h *RestHandler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	respErr := h.verificationService.Verify(r.Context(), r.URL.Query().Get("token"))
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *RestHandler) HandleResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := principal(r).UserID
+
+	respErr := h.verificationService.Resend(r.Context(), userID.String())
+	if respErr != nil {
+		writeResponseErr(w, r, respErr)
+		return
+	}
+
+	w.WriteHeader(202)
+}
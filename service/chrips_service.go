@@ -2,32 +2,419 @@ package service
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/internal/search"
 	"github.com/karaMuha/go-chirpy/models"
 	"github.com/karaMuha/go-chirpy/sql/repositories"
 )
 
+// EditWindow limits how long after posting a chirp can still be edited.
+// Chirpy Red users get the Red window, or the Default one when Red is zero.
+// A zero Default means no limit for anyone.
+type EditWindow struct {
+	Default time.Duration
+	Red     time.Duration
+}
+
 type ChirpsService struct {
-	chripRepo repositories.ChirpsRepository
+	chripRepo  repositories.ChirpsStore
+	likesRepo  repositories.LikesStore
+	tagsRepo   repositories.TagsStore
+	usersRepo  repositories.UsersStore
+	editWindow EditWindow
+	// requireVerifiedEmail stops users from posting until they verified
+	// their email.
+	requireVerifiedEmail bool
 }
 
-func NewChripsService(chirpRepo repositories.ChirpsRepository) ChirpsService {
+func NewChripsService(
+	chirpRepo repositories.ChirpsStore,
+	likesRepo repositories.LikesStore,
+	tagsRepo repositories.TagsStore,
+	usersRepo repositories.UsersStore,
+	editWindow EditWindow,
+	requireVerifiedEmail bool,
+) ChirpsService {
 	return ChirpsService{
-		chripRepo: chirpRepo,
+		chripRepo:            chirpRepo,
+		likesRepo:            likesRepo,
+		tagsRepo:             tagsRepo,
+		usersRepo:            usersRepo,
+		editWindow:           editWindow,
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
+}
+
+const (
+	DefaultThreadDepth = 3
+	MaxThreadDepth     = 10
+)
+
+func (s *ChirpsService) CreateChrip(ctx context.Context, body, userID, replyToID string) (*models.Chirp, *models.ResponseErr) {
+	if respErr := s.checkMayPost(ctx, userID); respErr != nil {
+		return nil, respErr
+	}
+
+	if replyToID != "" {
+		parent, respErr := s.chripRepo.GetChirpByID(ctx, replyToID)
+		if respErr != nil {
+			return nil, respErr
+		}
+		if parent.DeletedAt != nil {
+			return nil, &models.ResponseErr{
+				Error:      "Cannot reply to a deleted chirp",
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+
+	chirp, respErr := s.chripRepo.CreateChirp(ctx, body, userID, replyToID, "")
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	s.saveEntitiesQuietly(ctx, chirp)
+
+	return chirp, nil
+}
+
+// Rechirp reposts chirpID for userID. An empty commentary makes a plain
+// rechirp, otherwise the result is a quote. Plain rechirps of a plain
+// rechirp point at the underlying original.
+func (s *ChirpsService) Rechirp(ctx context.Context, userID, chirpID, commentary string) (*models.Chirp, *models.ResponseErr) {
+	if respErr := s.checkMayPost(ctx, userID); respErr != nil {
+		return nil, respErr
+	}
+
+	original, respErr := s.chripRepo.GetChirpByID(ctx, chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if original.RepostOfID != nil && original.Body == "" {
+		original, respErr = s.chripRepo.GetChirpByID(ctx, original.RepostOfID.String())
+		if respErr != nil {
+			return nil, respErr
+		}
+	}
+	if original.DeletedAt != nil {
+		return nil, &models.ResponseErr{
+			Error:      "Cannot rechirp a deleted chirp",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	chirp, respErr := s.chripRepo.CreateChirp(ctx, commentary, userID, "", original.ID.String())
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	s.saveEntitiesQuietly(ctx, chirp)
+
+	chirps := []models.Chirp{*chirp}
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, userID, chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return &chirps[0], nil
+}
+
+func (s *ChirpsService) GetAll(ctx context.Context, viewerID, authorID, sorting, cursor string, limit int) (*models.ChirpPage, *models.ResponseErr) {
+	sorting = strings.ToUpper(sorting)
+	if sorting != "ASC" && sorting != "DESC" {
+		return nil, models.NewFieldError("sort", "sort must be asc or desc")
+	}
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	chirps, respErr := s.chripRepo.GetAll(ctx, authorID, sorting, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := newChirpPage(*chirps, limit)
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, page.Chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return page, nil
+}
+
+func (s *ChirpsService) Search(ctx context.Context, viewerID, text, cursor string, limit int) (*models.ChirpPage, *models.ResponseErr) {
+	query, err := search.ParseQuery(text)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	offset, err := pagination.DecodeOffset(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	chirps, respErr := s.chripRepo.Search(ctx, query, offset, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := models.ChirpPage{
+		Chirps: make([]models.Chirp, 0, limit),
+	}
+	results := *chirps
+	if len(results) > limit {
+		results = results[:limit]
+		page.NextCursor = pagination.EncodeOffset(offset + limit)
+	}
+	page.Chirps = append(page.Chirps, results...)
+
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, page.Chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return &page, nil
+}
+
+func (s *ChirpsService) GetByID(ctx context.Context, viewerID, chirpID string) (*models.Chirp, *models.ResponseErr) {
+	chirp, respErr := s.chripRepo.GetChirpByID(ctx, chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	chirps := []models.Chirp{*chirp}
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return &chirps[0], nil
+}
+
+// GetThread returns a chirp with its ancestors and a page of its direct
+// replies. Each reply carries its own replies down to depth levels below
+// the chirp.
+func (s *ChirpsService) GetThread(ctx context.Context, viewerID, chirpID, cursor string, limit, depth int) (*models.ChirpThread, *models.ResponseErr) {
+	chirp, respErr := s.GetByID(ctx, viewerID, chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	ancestors, respErr := s.chripRepo.GetAncestors(ctx, chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, *ancestors); respErr != nil {
+		return nil, respErr
+	}
+
+	replies, respErr := s.chripRepo.GetReplies(ctx, chirpID, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+	page := newChirpPage(*replies, limit)
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, page.Chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	// Fetch one level more than is returned so nodes at the depth limit
+	// can tell whether they have further replies.
+	children := make(map[uuid.UUID][]models.Chirp)
+	if len(page.Chirps) > 0 {
+		rootIDs := make([]string, 0, len(page.Chirps))
+		for _, reply := range page.Chirps {
+			rootIDs = append(rootIDs, reply.ID.String())
+		}
+		descendants, respErr := s.chripRepo.GetDescendants(ctx, rootIDs, depth)
+		if respErr != nil {
+			return nil, respErr
+		}
+		if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, *descendants); respErr != nil {
+			return nil, respErr
+		}
+		for _, descendant := range *descendants {
+			children[*descendant.ReplyToID] = append(children[*descendant.ReplyToID], descendant)
+		}
+	}
+
+	thread := models.ChirpThread{
+		Chirp:      *chirp,
+		Ancestors:  make([]models.Chirp, 0, len(*ancestors)),
+		Replies:    make([]models.ThreadNode, 0, len(page.Chirps)),
+		NextCursor: page.NextCursor,
 	}
+	thread.Ancestors = append(thread.Ancestors, *ancestors...)
+	for _, reply := range page.Chirps {
+		thread.Replies = append(thread.Replies, buildThreadNode(reply, children, 1, depth))
+	}
+
+	return &thread, nil
 }
 
-func (s *ChirpsService) CreateChrip(ctx context.Context, body, userID string) (*models.Chirp, *models.ResponseErr) {
-	return s.chripRepo.CreateChirp(ctx, body, userID)
+func buildThreadNode(chirp models.Chirp, children map[uuid.UUID][]models.Chirp, level, depth int) models.ThreadNode {
+	node := models.ThreadNode{Chirp: chirp}
+	if level >= depth {
+		node.HasMoreReplies = len(children[chirp.ID]) > 0
+		return node
+	}
+
+	for _, child := range children[chirp.ID] {
+		node.Replies = append(node.Replies, buildThreadNode(child, children, level+1, depth))
+	}
+
+	return node
 }
 
-func (s *ChirpsService) GetAll(ctx context.Context, authorID, sorting string) (*[]models.Chirp, *models.ResponseErr) {
-	return s.chripRepo.GetAll(ctx, authorID, sorting)
+// Edit replaces the body of one of userID's chirps. The body must already
+// have passed Service.ValidateChirp. Plain rechirps have nothing to edit.
+func (s *ChirpsService) Edit(ctx context.Context, userID, chirpID, body string) (*models.Chirp, *models.ResponseErr) {
+	chirp, respErr := s.chripRepo.GetChirpByID(ctx, chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	if chirp.UserID.String() != userID {
+		return nil, &models.ResponseErr{
+			Error:      "Not your chirp",
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	if chirp.DeletedAt != nil {
+		return nil, &models.ResponseErr{
+			Error:      "Cannot edit a deleted chirp",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if chirp.RepostOfID != nil && chirp.Body == "" {
+		return nil, &models.ResponseErr{
+			Error:      "Cannot edit a plain rechirp",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if body == "" {
+		return nil, models.NewFieldError("body", "Chirp body cannot be empty")
+	}
+
+	if respErr := s.checkNotSuspended(ctx, userID); respErr != nil {
+		return nil, respErr
+	}
+	if respErr := s.checkEditWindow(ctx, userID, chirp); respErr != nil {
+		return nil, respErr
+	}
+
+	if body != chirp.Body {
+		chirp, respErr = s.chripRepo.UpdateChirp(ctx, chirpID, body)
+		if respErr != nil {
+			return nil, respErr
+		}
+
+		if respErr := s.tagsRepo.DeleteEntities(ctx, chirpID); respErr != nil {
+			log.Printf("Could not clear hashtags and mentions of chirp %s: %s", chirpID, respErr.Error)
+		} else {
+			s.saveEntitiesQuietly(ctx, chirp)
+		}
+	}
+
+	chirps := []models.Chirp{*chirp}
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, userID, chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return &chirps[0], nil
 }
 
-func (s *ChirpsService) GetByID(ctx context.Context, chirpID string) (*models.Chirp, *models.ResponseErr) {
-	return s.chripRepo.GetChirpByID(ctx, chirpID)
+func (s *ChirpsService) checkEditWindow(ctx context.Context, userID string, chirp *models.Chirp) *models.ResponseErr {
+	window := s.editWindow.Default
+	if window == 0 {
+		return nil
+	}
+
+	user, respErr := s.usersRepo.GetByID(ctx, userID)
+	if respErr != nil {
+		return respErr
+	}
+	if user.IsChirpyRed && s.editWindow.Red != 0 {
+		window = s.editWindow.Red
+	}
+
+	if time.Since(chirp.CreatedAt) > window {
+		return &models.ResponseErr{
+			Error:      "Edit window has passed",
+			StatusCode: http.StatusForbidden,
+		}
+	}
+
+	return nil
+}
+
+// checkNotSuspended stops suspended users from posting while their access
+// token is still valid.
+func (s *ChirpsService) checkNotSuspended(ctx context.Context, userID string) *models.ResponseErr {
+	user, respErr := s.usersRepo.GetByID(ctx, userID)
+	if respErr != nil {
+		return respErr
+	}
+	if user.SuspendedAt != nil {
+		return errSuspended()
+	}
+	return nil
+}
+
+// checkMayPost applies checkNotSuspended and, when configured, the email
+// verification policy to new chirps and rechirps.
+func (s *ChirpsService) checkMayPost(ctx context.Context, userID string) *models.ResponseErr {
+	user, respErr := s.usersRepo.GetByID(ctx, userID)
+	if respErr != nil {
+		return respErr
+	}
+	if user.SuspendedAt != nil {
+		return errSuspended()
+	}
+	if s.requireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return &models.ResponseErr{
+			Error:      "Verify your email before posting",
+			StatusCode: http.StatusForbidden,
+			Code:       models.CodeEmailNotVerified,
+		}
+	}
+	return nil
+}
+
+// GetRevisions lists the earlier bodies of a chirp, oldest first.
+func (s *ChirpsService) GetRevisions(ctx context.Context, chirpID string) (*[]models.ChirpRevision, *models.ResponseErr) {
+	chirp, respErr := s.chripRepo.GetChirpByID(ctx, chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if chirp.DeletedAt != nil {
+		return nil, &models.ResponseErr{
+			Error:      "Chirp not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return s.chripRepo.GetRevisions(ctx, chirpID)
 }
 
 func (s *ChirpsService) Delete(ctx context.Context, userID, chirpID string) *models.ResponseErr {
@@ -45,3 +432,62 @@ func (s *ChirpsService) Delete(ctx context.Context, userID, chirpID string) *mod
 
 	return s.chripRepo.DeleteChirp(ctx, chirpID)
 }
+
+// decorateChirps prepares chirps for a response. It embeds the original of
+// every rechirp, or flags it unavailable when the original is gone, and
+// fills in like information on both the chirps and the embedded originals.
+func decorateChirps(ctx context.Context, chirpRepo repositories.ChirpsStore, likesRepo repositories.LikesStore, viewerID string, chirps []models.Chirp) *models.ResponseErr {
+	var originalIDs []string
+	seen := make(map[uuid.UUID]bool)
+	for _, chirp := range chirps {
+		if chirp.RepostOfID != nil && !seen[*chirp.RepostOfID] {
+			seen[*chirp.RepostOfID] = true
+			originalIDs = append(originalIDs, chirp.RepostOfID.String())
+		}
+	}
+
+	originals := make(map[uuid.UUID]models.Chirp)
+	if len(originalIDs) > 0 {
+		found, respErr := chirpRepo.GetByIDs(ctx, originalIDs)
+		if respErr != nil {
+			return respErr
+		}
+		if respErr := annotateLikes(ctx, likesRepo, viewerID, *found); respErr != nil {
+			return respErr
+		}
+		for _, original := range *found {
+			if original.DeletedAt == nil {
+				originals[original.ID] = original
+			}
+		}
+	}
+
+	for i := range chirps {
+		if chirps[i].RepostOfID == nil {
+			continue
+		}
+		if original, ok := originals[*chirps[i].RepostOfID]; ok {
+			chirps[i].RepostOf = &original
+		} else {
+			chirps[i].RepostUnavailable = true
+		}
+	}
+
+	return annotateLikes(ctx, likesRepo, viewerID, chirps)
+}
+
+// newChirpPage trims a result fetched with limit+1 rows down to limit and
+// sets the next cursor when the extra row shows there is more to read.
+func newChirpPage(chirps []models.Chirp, limit int) *models.ChirpPage {
+	page := models.ChirpPage{
+		Chirps: make([]models.Chirp, 0, limit),
+	}
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[len(chirps)-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	page.Chirps = append(page.Chirps, chirps...)
+
+	return &page
+}
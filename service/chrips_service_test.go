@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+func newMemoryChirpsService(editWindow EditWindow) (ChirpsService, *repositories.MemoryUsersRepository) {
+	db := repositories.NewMemoryDB()
+	chirps := repositories.NewMemoryChirpsRepository(db)
+	likes := repositories.NewMemoryLikesRepository(db)
+	tags := repositories.NewMemoryTagsRepository(db)
+	users := repositories.NewMemoryUsersRepository(db)
+	return NewChripsService(&chirps, &likes, &tags, &users, editWindow, false), &users
+}
+
+func TestEditWindow(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		window     EditWindow
+		red        bool
+		wantStatus int
+	}{
+		{"no limit", EditWindow{}, false, 0},
+		{"default passed", EditWindow{Default: time.Nanosecond}, false, http.StatusForbidden},
+		{"red window open", EditWindow{Default: time.Nanosecond, Red: time.Hour}, true, 0},
+		{"red window ignored for others", EditWindow{Default: time.Nanosecond, Red: time.Hour}, false, http.StatusForbidden},
+		{"red falls back to passed default", EditWindow{Default: time.Nanosecond}, true, http.StatusForbidden},
+		{"red falls back to open default", EditWindow{Default: time.Hour}, true, 0},
+	}
+	for _, tt := range tests {
+		chirps, users := newMemoryChirpsService(tt.window)
+		user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+		if tt.red {
+			users.UpgradeToRed(ctx, user.ID.String())
+		}
+		chirp, respErr := chirps.CreateChrip(ctx, "first", user.ID.String(), "")
+		if respErr != nil {
+			t.Fatalf("%s: expected no error but got error: %v", tt.name, respErr.Error)
+		}
+		time.Sleep(time.Millisecond)
+
+		_, respErr = chirps.Edit(ctx, user.ID.String(), chirp.ID.String(), "second")
+		if tt.wantStatus == 0 && respErr != nil {
+			t.Errorf("%s: expected edit to be allowed but got error: %v", tt.name, respErr.Error)
+		}
+		if tt.wantStatus != 0 && (respErr == nil || respErr.StatusCode != tt.wantStatus) {
+			t.Errorf("%s: expected status %d but got: %v", tt.name, tt.wantStatus, respErr)
+		}
+	}
+}
+
+func TestRechirpOfRechirpPointsAtOriginal(t *testing.T) {
+	ctx := context.Background()
+	chirps, users := newMemoryChirpsService(EditWindow{})
+	author, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	fan, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	other, _ := users.CreateUser(ctx, "c@example.com", "hash", models.Profile{Username: "c"})
+	original, _ := chirps.CreateChrip(ctx, "original", author.ID.String(), "")
+
+	plain, respErr := chirps.Rechirp(ctx, fan.ID.String(), original.ID.String(), "")
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	again, respErr := chirps.Rechirp(ctx, other.ID.String(), plain.ID.String(), "")
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if again.RepostOfID == nil || *again.RepostOfID != original.ID || again.RepostOf == nil || again.RepostOf.Body != "original" {
+		t.Errorf("Expected a rechirp of a rechirp to point at the original but got %+v", again)
+	}
+
+	quote, _ := chirps.Rechirp(ctx, author.ID.String(), original.ID.String(), "me again")
+	quoted, respErr := chirps.Rechirp(ctx, other.ID.String(), quote.ID.String(), "")
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if quoted.RepostOfID == nil || *quoted.RepostOfID != quote.ID {
+		t.Errorf("Expected a rechirp of a quote to point at the quote but got %+v", quoted)
+	}
+}
+
+func TestRepostUnavailableAfterOriginalDeleted(t *testing.T) {
+	ctx := context.Background()
+	chirps, users := newMemoryChirpsService(EditWindow{})
+	author, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	fan, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	original, _ := chirps.CreateChrip(ctx, "original", author.ID.String(), "")
+	quote, _ := chirps.Rechirp(ctx, fan.ID.String(), original.ID.String(), "so true")
+	if quote.RepostUnavailable || quote.RepostOf == nil {
+		t.Fatalf("Expected the quote to embed its original but got %+v", quote)
+	}
+
+	if respErr := chirps.Delete(ctx, author.ID.String(), original.ID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	found, respErr := chirps.GetByID(ctx, "", quote.ID.String())
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if !found.RepostUnavailable || found.RepostOf != nil {
+		t.Errorf("Expected the quote to mark its original unavailable but got %+v", found)
+	}
+}
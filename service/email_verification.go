@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/mailer"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+const (
+	DefaultEmailVerificationTTL = 48 * time.Hour
+	MaxEmailLength              = 254
+)
+
+type EmailVerificationService struct {
+	tokensRepo repositories.UserTokensStore
+	usersRepo  repositories.UsersStore
+	mailer     mailer.Mailer
+	baseURL    string
+	ttl        time.Duration
+}
+
+func NewEmailVerificationService(
+	tokensRepo repositories.UserTokensStore,
+	usersRepo repositories.UsersStore,
+	mailer mailer.Mailer,
+	baseURL string,
+	ttl time.Duration,
+) EmailVerificationService {
+	if ttl == 0 {
+		ttl = DefaultEmailVerificationTTL
+	}
+	return EmailVerificationService{
+		tokensRepo: tokensRepo,
+		usersRepo:  usersRepo,
+		mailer:     mailer,
+		baseURL:    baseURL,
+		ttl:        ttl,
+	}
+}
+
+// SendVerification mails a verification link for the user's current email.
+// Links sent earlier stop working.
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *models.User) *models.ResponseErr {
+	if user.EmailVerifiedAt != nil {
+		return &models.ResponseErr{
+			Error:      "Email already verified",
+			StatusCode: http.StatusConflict,
+		}
+	}
+
+	userID := user.ID.String()
+	if respErr := s.tokensRepo.DeleteTokens(ctx, userID, models.TokenPurposeEmailVerification); respErr != nil {
+		return respErr
+	}
+
+	token, err := auth.MakeRandomToken()
+	if err != nil {
+		return &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	respErr := s.tokensRepo.CreateToken(ctx, auth.HashToken(token), userID, models.TokenPurposeEmailVerification, time.Now().Add(s.ttl))
+	if respErr != nil {
+		return respErr
+	}
+
+	link := s.baseURL + "/api/verify?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Chirpy email",
+		Body:    fmt.Sprintf("Open %s within %s to confirm this is your email address.", link, s.ttl),
+	})
+	if err != nil {
+		return &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+// sendVerificationQuietly is used right after signup or an email change,
+// where a mail failure should not undo the account change. The user can ask
+// for a new link.
+func (s *EmailVerificationService) sendVerificationQuietly(ctx context.Context, user *models.User) {
+	if respErr := s.SendVerification(ctx, user); respErr != nil {
+		log.Printf("Could not send verification email to user %s: %s", user.ID, respErr.Error)
+	}
+}
+
+// Resend mails a fresh verification link to the user.
+func (s *EmailVerificationService) Resend(ctx context.Context, userID string) *models.ResponseErr {
+	user, respErr := s.usersRepo.GetByID(ctx, userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	return s.SendVerification(ctx, user)
+}
+
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) *models.ResponseErr {
+	userToken, respErr := s.tokensRepo.ConsumeToken(ctx, auth.HashToken(token), models.TokenPurposeEmailVerification)
+	if respErr != nil {
+		return respErr
+	}
+
+	return s.usersRepo.MarkEmailVerified(ctx, userToken.UserID.String())
+}
+
+// normalizeEmail trims the address and lower-cases its domain, which is
+// case-insensitive. The local part is kept as typed.
+func normalizeEmail(email string) string {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	return email[:at] + strings.ToLower(email[at:])
+}
+
+// validateEmail accepts a bare address such as "a@example.com" whose domain
+// has at least one dot. Display names and comments are rejected.
+func validateEmail(email string) *models.ResponseErr {
+	invalid := models.NewFieldError("email", "email must be a valid address like name@example.com")
+	if email == "" || len(email) > MaxEmailLength {
+		return invalid
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" || address.Address != email {
+		return invalid
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return invalid
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+type FollowsService struct {
+	followsRepo repositories.FollowsStore
+	usersRepo   repositories.UsersStore
+	chirpRepo   repositories.ChirpsStore
+	likesRepo   repositories.LikesStore
+}
+
+func NewFollowsService(
+	followsRepo repositories.FollowsStore,
+	usersRepo repositories.UsersStore,
+	chirpRepo repositories.ChirpsStore,
+	likesRepo repositories.LikesStore,
+) FollowsService {
+	return FollowsService{
+		followsRepo: followsRepo,
+		usersRepo:   usersRepo,
+		chirpRepo:   chirpRepo,
+		likesRepo:   likesRepo,
+	}
+}
+
+func (s *FollowsService) Follow(ctx context.Context, followerID, followeeID string) *models.ResponseErr {
+	if followerID == followeeID {
+		return &models.ResponseErr{
+			Error:      "You cannot follow yourself",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	if _, respErr := s.usersRepo.GetByID(ctx, followeeID); respErr != nil {
+		return respErr
+	}
+
+	return s.followsRepo.Follow(ctx, followerID, followeeID)
+}
+
+func (s *FollowsService) Unfollow(ctx context.Context, followerID, followeeID string) *models.ResponseErr {
+	return s.followsRepo.Unfollow(ctx, followerID, followeeID)
+}
+
+func (s *FollowsService) GetFollowers(ctx context.Context, userID, cursor string, limit int) (*models.FollowPage, *models.ResponseErr) {
+	after, respErr := s.decodeCursorForUser(ctx, userID, cursor)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	follows, respErr := s.followsRepo.GetFollowers(ctx, userID, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	return newFollowPage(*follows, limit, func(follow models.Follow) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: follow.CreatedAt, ID: follow.FollowerID}
+	}), nil
+}
+
+func (s *FollowsService) GetFollowing(ctx context.Context, userID, cursor string, limit int) (*models.FollowPage, *models.ResponseErr) {
+	after, respErr := s.decodeCursorForUser(ctx, userID, cursor)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	follows, respErr := s.followsRepo.GetFollowing(ctx, userID, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	return newFollowPage(*follows, limit, func(follow models.Follow) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: follow.CreatedAt, ID: follow.FolloweeID}
+	}), nil
+}
+
+// Timeline returns chirps from the accounts userID follows, newest first.
+func (s *FollowsService) Timeline(ctx context.Context, userID, cursor string, limit int) (*models.ChirpPage, *models.ResponseErr) {
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	chirps, respErr := s.chirpRepo.GetTimeline(ctx, userID, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := newChirpPage(*chirps, limit)
+	if respErr := decorateChirps(ctx, s.chirpRepo, s.likesRepo, userID, page.Chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return page, nil
+}
+
+func (s *FollowsService) decodeCursorForUser(ctx context.Context, userID, cursor string) (*pagination.Cursor, *models.ResponseErr) {
+	if _, respErr := s.usersRepo.GetByID(ctx, userID); respErr != nil {
+		return nil, respErr
+	}
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return after, nil
+}
+
+func newFollowPage(follows []models.Follow, limit int, cursorFor func(models.Follow) pagination.Cursor) *models.FollowPage {
+	page := models.FollowPage{
+		Follows: make([]models.Follow, 0, limit),
+	}
+	if len(follows) > limit {
+		follows = follows[:limit]
+		page.NextCursor = cursorFor(follows[len(follows)-1]).Encode()
+	}
+	page.Follows = append(page.Follows, follows...)
+
+	return &page
+}
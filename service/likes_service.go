@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+type LikesService struct {
+	likesRepo repositories.LikesStore
+	chirpRepo repositories.ChirpsStore
+	usersRepo repositories.UsersStore
+}
+
+func NewLikesService(
+	likesRepo repositories.LikesStore,
+	chirpRepo repositories.ChirpsStore,
+	usersRepo repositories.UsersStore,
+) LikesService {
+	return LikesService{
+		likesRepo: likesRepo,
+		chirpRepo: chirpRepo,
+		usersRepo: usersRepo,
+	}
+}
+
+func (s *LikesService) Like(ctx context.Context, userID, chirpID string) *models.ResponseErr {
+	chirp, respErr := s.chirpRepo.GetChirpByID(ctx, chirpID)
+	if respErr != nil {
+		return respErr
+	}
+	if chirp.DeletedAt != nil {
+		return &models.ResponseErr{
+			Error:      "Cannot like a deleted chirp",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return s.likesRepo.Like(ctx, userID, chirpID)
+}
+
+func (s *LikesService) Unlike(ctx context.Context, userID, chirpID string) *models.ResponseErr {
+	return s.likesRepo.Unlike(ctx, userID, chirpID)
+}
+
+// GetLikedChirps lists the chirps userID liked, most recently liked first.
+func (s *LikesService) GetLikedChirps(ctx context.Context, viewerID, userID, cursor string, limit int) (*models.ChirpPage, *models.ResponseErr) {
+	if _, respErr := s.usersRepo.GetByID(ctx, userID); respErr != nil {
+		return nil, respErr
+	}
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	likes, respErr := s.likesRepo.GetLikesByUser(ctx, userID, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := models.ChirpPage{
+		Chirps: make([]models.Chirp, 0, limit),
+	}
+	likeList := *likes
+	if len(likeList) > limit {
+		likeList = likeList[:limit]
+		last := likeList[len(likeList)-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ChirpID}.Encode()
+	}
+	if len(likeList) == 0 {
+		return &page, nil
+	}
+
+	chirpIDs := make([]string, 0, len(likeList))
+	for _, like := range likeList {
+		chirpIDs = append(chirpIDs, like.ChirpID.String())
+	}
+	chirps, respErr := s.chirpRepo.GetByIDs(ctx, chirpIDs)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	// Keep the order of the likes, GetByIDs does not preserve it.
+	byID := make(map[string]models.Chirp, len(*chirps))
+	for _, chirp := range *chirps {
+		byID[chirp.ID.String()] = chirp
+	}
+	for _, chirpID := range chirpIDs {
+		if chirp, ok := byID[chirpID]; ok {
+			page.Chirps = append(page.Chirps, chirp)
+		}
+	}
+
+	if respErr := decorateChirps(ctx, s.chirpRepo, s.likesRepo, viewerID, page.Chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return &page, nil
+}
+
+// annotateLikes fills in LikeCount on every chirp and, when viewerID is not
+// empty, whether the viewer liked it.
+func annotateLikes(ctx context.Context, likesRepo repositories.LikesStore, viewerID string, chirps []models.Chirp) *models.ResponseErr {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]string, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID.String())
+	}
+
+	counts, respErr := likesRepo.CountLikes(ctx, chirpIDs)
+	if respErr != nil {
+		return respErr
+	}
+
+	var liked map[uuid.UUID]bool
+	if viewerID != "" {
+		liked, respErr = likesRepo.GetLikedChirpIDs(ctx, viewerID, chirpIDs)
+		if respErr != nil {
+			return respErr
+		}
+	}
+
+	for i := range chirps {
+		chirps[i].LikeCount = counts[chirps[i].ID]
+		if viewerID != "" {
+			likedByMe := liked[chirps[i].ID]
+			chirps[i].LikedByMe = &likedByMe
+		}
+	}
+
+	return nil
+}
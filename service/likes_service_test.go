@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+func TestAnnotateLikes(t *testing.T) {
+	ctx := context.Background()
+	db := repositories.NewMemoryDB()
+	users := repositories.NewMemoryUsersRepository(db)
+	chirps := repositories.NewMemoryChirpsRepository(db)
+	likes := repositories.NewMemoryLikesRepository(db)
+
+	a, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	b, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	liked, _ := chirps.CreateChirp(ctx, "liked", a.ID.String(), "", "")
+	unliked, _ := chirps.CreateChirp(ctx, "unliked", a.ID.String(), "", "")
+	likes.Like(ctx, a.ID.String(), liked.ID.String())
+	likes.Like(ctx, b.ID.String(), liked.ID.String())
+
+	anonymous := []models.Chirp{*liked, *unliked}
+	if respErr := annotateLikes(ctx, &likes, "", anonymous); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if anonymous[0].LikeCount != 2 || anonymous[1].LikeCount != 0 {
+		t.Errorf("Expected like counts 2 and 0 but got %d and %d", anonymous[0].LikeCount, anonymous[1].LikeCount)
+	}
+	if anonymous[0].LikedByMe != nil || anonymous[1].LikedByMe != nil {
+		t.Error("Expected liked_by_me to be left out for anonymous callers")
+	}
+
+	viewed := []models.Chirp{*liked, *unliked}
+	if respErr := annotateLikes(ctx, &likes, a.ID.String(), viewed); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if viewed[0].LikedByMe == nil || !*viewed[0].LikedByMe {
+		t.Error("Expected the viewer's like to be reported")
+	}
+	if viewed[1].LikedByMe == nil || *viewed[1].LikedByMe {
+		t.Error("Expected an unliked chirp to report liked_by_me as false")
+	}
+
+	if respErr := annotateLikes(ctx, &likes, a.ID.String(), nil); respErr != nil {
+		t.Errorf("Expected no error for an empty page but got error: %v", respErr.Error)
+	}
+}
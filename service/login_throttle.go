@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/mailer"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+const (
+	// AccountFreeFailures is how many failed logins an account allows before
+	// it starts backing off, and when its owner is mailed an unlock link.
+	AccountFreeFailures = 5
+	// IPFreeFailures is higher than AccountFreeFailures since many users can
+	// share an address behind NAT.
+	IPFreeFailures = 20
+	// LoginBackoffBase is the wait once the free failures are used up. It
+	// doubles with each further failure up to LoginBackoffMax.
+	LoginBackoffBase = 30 * time.Second
+	LoginBackoffMax  = time.Hour
+	// LoginFailuresResetAfter is how long without failures it takes for the
+	// count to start over.
+	LoginFailuresResetAfter = 24 * time.Hour
+	DefaultAccountUnlockTTL = time.Hour
+)
+
+// LoginThrottleService slows down password guessing. Failed logins are
+// counted per account and per client address, and once a count passes its
+// free failures every further attempt has to wait twice as long as the
+// one before. The counts are kept by email whether or not an account
+// exists, so throttling does not reveal which emails are registered.
+type LoginThrottleService struct {
+	attemptsRepo repositories.LoginAttemptsStore
+	tokensRepo   repositories.UserTokensStore
+	usersRepo    repositories.UsersStore
+	mailer       mailer.Mailer
+	baseURL      string
+	unlockTTL    time.Duration
+}
+
+func NewLoginThrottleService(
+	attemptsRepo repositories.LoginAttemptsStore,
+	tokensRepo repositories.UserTokensStore,
+	usersRepo repositories.UsersStore,
+	mailer mailer.Mailer,
+	baseURL string,
+	unlockTTL time.Duration,
+) LoginThrottleService {
+	if unlockTTL == 0 {
+		unlockTTL = DefaultAccountUnlockTTL
+	}
+	return LoginThrottleService{
+		attemptsRepo: attemptsRepo,
+		tokensRepo:   tokensRepo,
+		usersRepo:    usersRepo,
+		mailer:       mailer,
+		baseURL:      baseURL,
+		unlockTTL:    unlockTTL,
+	}
+}
+
+// Check refuses a login for email while the account or the client's
+// address is backing off. Refused attempts go to the audit trail but do
+// not count as failures. user is nil when no account has the email.
+func (s *LoginThrottleService) Check(ctx context.Context, email string, user *models.User, client models.ClientInfo) *models.ResponseErr {
+	keys := []string{accountThrottleKey(email)}
+	if key := ipThrottleKey(client.IP); key != "" {
+		keys = append(keys, key)
+	}
+
+	throttles, respErr := s.attemptsRepo.GetLoginThrottles(ctx, keys)
+	if respErr != nil {
+		return respErr
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	for _, throttle := range throttles {
+		free := IPFreeFailures
+		if throttle.Key == keys[0] {
+			free = AccountFreeFailures
+		}
+		lockedUntil := throttle.LastFailureAt.Add(loginBackoff(throttle.Failures, free))
+		wait = max(wait, lockedUntil.Sub(now))
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	if respErr := s.record(ctx, email, user, models.LoginFailureThrottled, client); respErr != nil {
+		return respErr
+	}
+	return &models.ResponseErr{
+		Error:      "Too many failed login attempts, try again later",
+		StatusCode: http.StatusTooManyRequests,
+		Code:       models.CodeTooManyAttempts,
+		RetryAfter: wait,
+	}
+}
+
+// Fail records a failed login and counts it against the account and the
+// client's address. When the account starts backing off its owner gets an
+// unlock link.
+func (s *LoginThrottleService) Fail(ctx context.Context, email string, user *models.User, reason string, client models.ClientInfo) *models.ResponseErr {
+	if respErr := s.record(ctx, email, user, reason, client); respErr != nil {
+		return respErr
+	}
+
+	now := time.Now()
+	if key := ipThrottleKey(client.IP); key != "" {
+		if _, respErr := s.attemptsRepo.AddLoginFailure(ctx, key, now, LoginFailuresResetAfter); respErr != nil {
+			return respErr
+		}
+	}
+	throttle, respErr := s.attemptsRepo.AddLoginFailure(ctx, accountThrottleKey(email), now, LoginFailuresResetAfter)
+	if respErr != nil {
+		return respErr
+	}
+
+	if user != nil && throttle.Failures == AccountFreeFailures {
+		s.sendUnlockQuietly(ctx, user)
+	}
+	return nil
+}
+
+// Succeed forgets the failures of the account after a successful login.
+// The address keeps its count, otherwise logging into an own account would
+// let a client reset it between guesses at others.
+func (s *LoginThrottleService) Succeed(ctx context.Context, email string) *models.ResponseErr {
+	return s.attemptsRepo.ClearLoginThrottle(ctx, accountThrottleKey(email))
+}
+
+// Unlock forgets the failures of the account an unlock link was mailed
+// for.
+func (s *LoginThrottleService) Unlock(ctx context.Context, token string) *models.ResponseErr {
+	userToken, respErr := s.tokensRepo.ConsumeToken(ctx, auth.HashToken(token), models.TokenPurposeAccountUnlock)
+	if respErr != nil {
+		return respErr
+	}
+
+	user, respErr := s.usersRepo.GetByID(ctx, userToken.UserID.String())
+	if respErr != nil {
+		return respErr
+	}
+
+	return s.attemptsRepo.ClearLoginThrottle(ctx, accountThrottleKey(user.Email))
+}
+
+// ListAttempts returns a page of the failed login audit trail, newest
+// first.
+func (s *LoginThrottleService) ListAttempts(ctx context.Context, filter models.LoginAttemptFilter, cursor string, limit int) (*models.LoginAttemptPage, *models.ResponseErr) {
+	if filter.UserID != "" {
+		if respErr := models.ValidateID("user_id", filter.UserID); respErr != nil {
+			return nil, respErr
+		}
+	}
+	filter.Email = normalizeEmail(filter.Email)
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	attempts, respErr := s.attemptsRepo.ListLoginAttempts(ctx, filter, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := models.LoginAttemptPage{
+		LoginAttempts: make([]models.LoginAttempt, 0, limit),
+	}
+	if len(attempts) > limit {
+		attempts = attempts[:limit]
+		last := attempts[len(attempts)-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	page.LoginAttempts = append(page.LoginAttempts, attempts...)
+
+	return &page, nil
+}
+
+func (s *LoginThrottleService) record(ctx context.Context, email string, user *models.User, reason string, client models.ClientInfo) *models.ResponseErr {
+	attempt := models.LoginAttempt{
+		ID:        uuid.New(),
+		Email:     email,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+
+	return s.attemptsRepo.RecordLoginAttempt(ctx, attempt)
+}
+
+// sendUnlockQuietly mails the owner of a locked account a link that lifts
+// the lock. Failing to send must not change the login response, which
+// would tell the caller the account exists.
+func (s *LoginThrottleService) sendUnlockQuietly(ctx context.Context, user *models.User) {
+	userID := user.ID.String()
+	if respErr := s.tokensRepo.DeleteTokens(ctx, userID, models.TokenPurposeAccountUnlock); respErr != nil {
+		log.Printf("Could not send unlock email to user %s: %s", userID, respErr.Error)
+		return
+	}
+
+	token, err := auth.MakeRandomToken()
+	if err != nil {
+		log.Printf("Could not send unlock email to user %s: %v", userID, err)
+		return
+	}
+	respErr := s.tokensRepo.CreateToken(ctx, auth.HashToken(token), userID, models.TokenPurposeAccountUnlock, time.Now().Add(s.unlockTTL))
+	if respErr != nil {
+		log.Printf("Could not send unlock email to user %s: %s", userID, respErr.Error)
+		return
+	}
+
+	link := s.baseURL + "/api/login/unlock?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Chirpy account was locked",
+		Body: fmt.Sprintf(
+			"There were several failed attempts to log into your Chirpy account, so logins are paused for a while.\n\n"+
+				"If it was you, open %s within %s to log in again right away. If it was not, consider changing your password.",
+			link, s.unlockTTL,
+		),
+	})
+	if err != nil {
+		log.Printf("Could not send unlock email to user %s: %v", userID, err)
+	}
+}
+
+// loginBackoff is how long to wait after the last of failures when free of
+// them are allowed without waiting.
+func loginBackoff(failures, free int) time.Duration {
+	if failures < free {
+		return 0
+	}
+	delay := LoginBackoffBase
+	for i := free; i < failures && delay < LoginBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, LoginBackoffMax)
+}
+
+func accountThrottleKey(email string) string {
+	return "email:" + strings.ToLower(email)
+}
+
+// ipThrottleKey names the counter of a client address. IPv6 clients are
+// counted by /64, which a single host can usually pick addresses from.
+func ipThrottleKey(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	if addr.Is6() {
+		prefix, _ := addr.Prefix(64)
+		return "ip:" + prefix.String()
+	}
+	return "ip:" + addr.String()
+}
+
+// dummyPasswordHash is compared against when the email belongs to no
+// account, so those logins take as long as ones with a wrong password.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := auth.HashPassword("not the password of any account")
+	if err != nil {
+		log.Printf("Could not hash dummy password: %v", err)
+	}
+	return hash
+})
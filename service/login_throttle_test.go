@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/mailer"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		free     int
+		expected time.Duration
+	}{
+		{0, 5, 0},
+		{4, 5, 0},
+		{5, 5, LoginBackoffBase},
+		{6, 5, 2 * LoginBackoffBase},
+		{8, 5, 8 * LoginBackoffBase},
+		{12, 5, LoginBackoffMax},
+		{1000, 5, LoginBackoffMax},
+		{20, 20, LoginBackoffBase},
+		{0, 0, LoginBackoffBase},
+	}
+	for _, tt := range tests {
+		if backoff := loginBackoff(tt.failures, tt.free); backoff != tt.expected {
+			t.Errorf("%d failures with %d free: expected %v but got %v", tt.failures, tt.free, tt.expected, backoff)
+		}
+	}
+}
+
+func TestIPThrottleKey(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"192.0.2.1", "ip:192.0.2.1"},
+		{"::ffff:192.0.2.1", "ip:192.0.2.1"},
+		{"2001:db8:1:2:3:4:5:6", "ip:2001:db8:1:2::/64"},
+		{"2001:db8:1:2:ffff::1", "ip:2001:db8:1:2::/64"},
+		{"2001:db8:1:3::1", "ip:2001:db8:1:3::/64"},
+		{"", ""},
+		{"not an ip", ""},
+		{"192.0.2.1:8080", ""},
+	}
+	for _, tt := range tests {
+		if key := ipThrottleKey(tt.ip); key != tt.expected {
+			t.Errorf("%q: expected %q but got %q", tt.ip, tt.expected, key)
+		}
+	}
+}
+
+func TestLoginUnknownEmailFailsLikeWrongPassword(t *testing.T) {
+	ctx := context.Background()
+	db := repositories.NewMemoryDB()
+	users := repositories.NewMemoryUsersRepository(db)
+	attempts := repositories.NewMemoryLoginAttemptsRepository(db)
+	tokens := repositories.NewMemoryUserTokensRepository(db)
+	throttle := NewLoginThrottleService(&attempts, &tokens, &users, mailer.NewLogMailer(), "http://localhost:8080", 0)
+	usersService := NewUsersService(&users, nil, nil, nil, nil, nil, &throttle, nil)
+
+	hash, _ := auth.HashPassword("correct horse")
+	users.CreateUser(ctx, "a@example.com", hash, models.Profile{Username: "a"})
+	client := models.ClientInfo{IP: "192.0.2.1"}
+
+	_, _, wrongPassword := usersService.Login(ctx, "a@example.com", "wrong", 0, client)
+	_, _, unknownEmail := usersService.Login(ctx, "b@example.com", "wrong", 0, client)
+	if wrongPassword == nil || unknownEmail == nil {
+		t.Fatalf("Expected both logins to fail but got %v and %v", wrongPassword, unknownEmail)
+	}
+	if !reflect.DeepEqual(wrongPassword, unknownEmail) {
+		t.Errorf("Expected an unknown email to fail like a wrong password but got %+v and %+v", unknownEmail, wrongPassword)
+	}
+	if wrongPassword.Code != models.CodeLoginFailed {
+		t.Errorf("Expected code %q but got %+v", models.CodeLoginFailed, wrongPassword)
+	}
+
+	recorded, _ := attempts.ListLoginAttempts(ctx, models.LoginAttemptFilter{IP: client.IP}, nil, 10)
+	reasons := map[string]bool{}
+	for _, attempt := range recorded {
+		reasons[attempt.Reason] = true
+	}
+	if len(recorded) != 2 || !reasons[models.LoginFailureWrongPassword] || !reasons[models.LoginFailureUnknownEmail] {
+		t.Errorf("Expected the audit trail to tell both failures apart but got %+v", recorded)
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/oidc"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+// OIDCLoginTTL is how long a user has to finish signing in at the provider.
+const OIDCLoginTTL = 10 * time.Minute
+
+// OIDCService signs users in through external OpenID Connect providers.
+type OIDCService struct {
+	providers      map[string]*oidc.Provider
+	identitiesRepo repositories.IdentitiesStore
+	users          *UsersService
+}
+
+func NewOIDCService(
+	providers []*oidc.Provider,
+	identitiesRepo repositories.IdentitiesStore,
+	users *UsersService,
+) OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return OIDCService{
+		providers:      byName,
+		identitiesRepo: identitiesRepo,
+		users:          users,
+	}
+}
+
+// BeginLogin returns the provider URL to send the user to, and a state token
+// the caller has to keep, typically in a cookie, and hand to CompleteLogin.
+func (s *OIDCService) BeginLogin(ctx context.Context, providerName string) (string, string, *models.ResponseErr) {
+	provider, respErr := s.provider(providerName)
+	if respErr != nil {
+		return "", "", respErr
+	}
+
+	state := auth.OIDCState{Provider: providerName}
+	var err error
+	for _, value := range []*string{&state.State, &state.Nonce} {
+		if *value, err = auth.MakeRandomToken(); err != nil {
+			return "", "", &models.ResponseErr{
+				Error:      err.Error(),
+				StatusCode: http.StatusInternalServerError,
+			}
+		}
+	}
+	if state.CodeVerifier, err = oidc.GenerateVerifier(); err != nil {
+		return "", "", &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, oidc.S256Challenge(state.CodeVerifier))
+	if err != nil {
+		return "", "", errProvider(err)
+	}
+	stateToken, err := auth.MakeOIDCStateToken(state, s.users.appState.Keys, OIDCLoginTTL)
+	if err != nil {
+		return "", "", &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	return authURL, stateToken, nil
+}
+
+// CompleteLogin handles the provider's callback. The provider account is
+// matched to a user by a previous link, then by verified email, and a new
+// user is created when neither exists. Like Login it returns either the user
+// with fresh tokens or an MFA challenge.
+func (s *OIDCService) CompleteLogin(ctx context.Context, providerName, code, state, stateToken string, client models.ClientInfo) (*models.User, *models.MFAChallenge, *models.ResponseErr) {
+	provider, respErr := s.provider(providerName)
+	if respErr != nil {
+		return nil, nil, respErr
+	}
+
+	saved, err := auth.ParseOIDCStateToken(stateToken, s.users.appState.Keys)
+	if err != nil || saved.Provider != providerName || saved.State != state {
+		return nil, nil, &models.ResponseErr{
+			Error:      "Login expired or was started elsewhere, please try again",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	token, err := provider.Exchange(ctx, code, saved.CodeVerifier)
+	if err != nil {
+		return nil, nil, errProviderLogin(err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, saved.Nonce)
+	if err != nil {
+		return nil, nil, errProviderLogin(err)
+	}
+
+	user, respErr := s.resolveUser(ctx, providerName, claims)
+	if respErr != nil {
+		return nil, nil, respErr
+	}
+
+	return s.users.finishLogin(ctx, user, AccessTokenTTL, client)
+}
+
+func (s *OIDCService) resolveUser(ctx context.Context, providerName string, claims *oidc.IDToken) (*models.User, *models.ResponseErr) {
+	identity, respErr := s.identitiesRepo.GetIdentity(ctx, providerName, claims.Subject)
+	if respErr == nil {
+		return s.users.usersRepository.GetByID(ctx, identity.UserID.String())
+	}
+	if respErr.StatusCode != http.StatusNotFound {
+		return nil, respErr
+	}
+
+	// Only an address the provider vouches for may be matched, otherwise
+	// anyone could claim an existing account at a lax provider.
+	email := normalizeEmail(claims.Email)
+	if !claims.EmailVerified || email == "" {
+		return nil, &models.ResponseErr{
+			Error:      "The provider did not confirm a verified email address",
+			StatusCode: http.StatusForbidden,
+			Code:       models.CodeEmailNotVerified,
+		}
+	}
+	if respErr := validateEmail(email); respErr != nil {
+		return nil, respErr
+	}
+
+	user, respErr := s.users.usersRepository.GetByEmail(ctx, email)
+	switch {
+	case respErr == nil:
+		// Whoever registered an unverified address may not own it, linking
+		// would let them keep a password to the provider user's account.
+		if user.EmailVerifiedAt == nil {
+			return nil, &models.ResponseErr{
+				Error:      "An account with this email exists, sign in with your password and verify the email first",
+				StatusCode: http.StatusConflict,
+			}
+		}
+	case respErr.StatusCode == http.StatusNotFound:
+		if user, respErr = s.createUser(ctx, email); respErr != nil {
+			return nil, respErr
+		}
+	default:
+		return nil, respErr
+	}
+
+	if _, respErr := s.identitiesRepo.LinkIdentity(ctx, providerName, claims.Subject, user.ID.String(), email); respErr != nil {
+		return nil, respErr
+	}
+	return user, nil
+}
+
+// createUser registers a verified account without a usable password. The
+// user can set one through the password reset flow.
+func (s *OIDCService) createUser(ctx context.Context, email string) (*models.User, *models.ResponseErr) {
+	username, respErr := s.users.defaultUsername(ctx, email)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	password, err := auth.MakeRandomToken()
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	user, respErr := s.users.usersRepository.CreateUser(ctx, email, hashedPassword, models.Profile{Username: username})
+	if respErr != nil {
+		return nil, respErr
+	}
+	if respErr := s.users.usersRepository.MarkEmailVerified(ctx, user.ID.String()); respErr != nil {
+		return nil, respErr
+	}
+
+	return s.users.usersRepository.GetByID(ctx, user.ID.String())
+}
+
+func (s *OIDCService) provider(name string) (*oidc.Provider, *models.ResponseErr) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, &models.ResponseErr{
+			Error:      "Unknown identity provider",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	return provider, nil
+}
+
+// errProvider reports a provider that could not be reached or is
+// misconfigured.
+func errProvider(err error) *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      err.Error(),
+		StatusCode: http.StatusBadGateway,
+	}
+}
+
+// errProviderLogin reports a code or ID token the provider flow did not
+// accept. The details are logged rather than shown.
+func errProviderLogin(err error) *models.ResponseErr {
+	log.Printf("OIDC login failed: %v", err)
+	return &models.ResponseErr{
+		Error:      "Could not sign in with the provider",
+		StatusCode: http.StatusUnauthorized,
+	}
+}
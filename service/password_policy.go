@@ -0,0 +1,72 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/password"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// PasswordPolicyService vets the passwords users pick against the
+// configured policy and a list of breached passwords.
+type PasswordPolicyService struct {
+	policy   password.Policy
+	breached password.RangeSource
+}
+
+// NewPasswordPolicyService returns a service enforcing policy. breached may
+// be nil to skip the breached password check.
+func NewPasswordPolicyService(policy password.Policy, breached password.RangeSource) PasswordPolicyService {
+	return PasswordPolicyService{
+		policy:   policy,
+		breached: breached,
+	}
+}
+
+// Validate returns a field error for every rule password breaks as the
+// password of the account with email.
+func (s *PasswordPolicyService) Validate(pw, email string) *models.ResponseErr {
+	problems := s.policy.Check(pw, email)
+	if pw != "" && s.breached != nil {
+		// A broken list must not stop everyone from picking a password.
+		breached, err := password.Breached(s.breached, pw)
+		if err != nil {
+			log.Printf("Could not check password against breached passwords: %v", err)
+		} else if breached {
+			problems = append(problems, "password appears in a known data breach, pick another one")
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	fields := make([]models.FieldError, 0, len(problems))
+	for _, problem := range problems {
+		fields = append(fields, models.FieldError{Field: "password", Message: problem})
+	}
+	return &models.ResponseErr{
+		Error:      strings.Join(problems, "; "),
+		StatusCode: http.StatusBadRequest,
+		Code:       models.CodeValidationFailed,
+		Fields:     fields,
+	}
+}
+
+// Hash validates pw and returns its hash to store.
+func (s *PasswordPolicyService) Hash(pw, email string) (string, *models.ResponseErr) {
+	if respErr := s.Validate(pw, email); respErr != nil {
+		return "", respErr
+	}
+
+	hashedPassword, err := auth.HashPassword(pw)
+	if err != nil {
+		return "", &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	return hashedPassword, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/mailer"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+const DefaultPasswordResetTTL = time.Hour
+
+type PasswordResetService struct {
+	tokensRepo repositories.UserTokensStore
+	usersRepo  repositories.UsersStore
+	revocation *TokenRevocationService
+	passwords  *PasswordPolicyService
+	mailer     mailer.Mailer
+	baseURL    string
+	ttl        time.Duration
+}
+
+func NewPasswordResetService(
+	tokensRepo repositories.UserTokensStore,
+	usersRepo repositories.UsersStore,
+	revocation *TokenRevocationService,
+	passwords *PasswordPolicyService,
+	mailer mailer.Mailer,
+	baseURL string,
+	ttl time.Duration,
+) PasswordResetService {
+	if ttl == 0 {
+		ttl = DefaultPasswordResetTTL
+	}
+	return PasswordResetService{
+		tokensRepo: tokensRepo,
+		usersRepo:  usersRepo,
+		revocation: revocation,
+		passwords:  passwords,
+		mailer:     mailer,
+		baseURL:    baseURL,
+		ttl:        ttl,
+	}
+}
+
+// Forgot mails a reset link to email. It succeeds whether or not an account
+// exists, so it cannot be used to find out which emails are registered.
+func (s *PasswordResetService) Forgot(ctx context.Context, email string) *models.ResponseErr {
+	user, respErr := s.usersRepo.GetByEmail(ctx, normalizeEmail(email))
+	if respErr != nil {
+		if respErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return respErr
+	}
+
+	token, err := auth.MakeRandomToken()
+	if err != nil {
+		return &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	respErr = s.tokensRepo.CreateToken(ctx, auth.HashToken(token), user.ID.String(), models.TokenPurposePasswordReset, time.Now().Add(s.ttl))
+	if respErr != nil {
+		return respErr
+	}
+
+	link := s.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password of your Chirpy account.\n\n"+
+				"Open %s within %s to choose a new one. If it was not you, ignore this email.",
+			link, s.ttl,
+		),
+	})
+	if err != nil {
+		// Failing here would tell the caller the account exists.
+		log.Printf("Could not send password reset email: %v", err)
+	}
+
+	return nil
+}
+
+// Reset sets a new password with a token from Forgot. The token and any
+// other outstanding reset tokens stop working, and every session of the
+// user is logged out.
+func (s *PasswordResetService) Reset(ctx context.Context, token, password string) *models.ResponseErr {
+	tokenHash := auth.HashToken(token)
+	// The token is only used up once the password passes, so a rejected
+	// password can be retried with the same link.
+	userToken, respErr := s.tokensRepo.LookupToken(ctx, tokenHash, models.TokenPurposePasswordReset)
+	if respErr != nil {
+		return respErr
+	}
+	user, respErr := s.usersRepo.GetByID(ctx, userToken.UserID.String())
+	if respErr != nil {
+		return respErr
+	}
+	hashedPassword, respErr := s.passwords.Hash(password, user.Email)
+	if respErr != nil {
+		return respErr
+	}
+
+	userToken, respErr = s.tokensRepo.ConsumeToken(ctx, tokenHash, models.TokenPurposePasswordReset)
+	if respErr != nil {
+		return respErr
+	}
+	userID := userToken.UserID.String()
+
+	if respErr := s.usersRepo.UpdatePassword(ctx, userID, hashedPassword); respErr != nil {
+		return respErr
+	}
+
+	if respErr := s.revocation.SignOutEverywhere(ctx, userID); respErr != nil {
+		return respErr
+	}
+
+	return s.tokensRepo.DeleteTokens(ctx, userID, models.TokenPurposePasswordReset)
+}
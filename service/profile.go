@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+const (
+	MinUsernameLength    = 3
+	MaxUsernameLength    = 30
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 160
+	MaxAvatarURLLength   = 2048
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ProfileUpdate carries the profile fields of an account update. Nil fields
+// are left unchanged.
+type ProfileUpdate struct {
+	Username    *string
+	DisplayName *string
+	Bio         *string
+	AvatarURL   *string
+}
+
+func (u ProfileUpdate) apply(profile models.Profile) models.Profile {
+	if u.Username != nil {
+		profile.Username = *u.Username
+	}
+	if u.DisplayName != nil {
+		profile.DisplayName = *u.DisplayName
+	}
+	if u.Bio != nil {
+		profile.Bio = *u.Bio
+	}
+	if u.AvatarURL != nil {
+		profile.AvatarURL = *u.AvatarURL
+	}
+	return profile
+}
+
+func normalizeProfile(profile models.Profile) models.Profile {
+	return models.Profile{
+		Username:    strings.TrimSpace(profile.Username),
+		DisplayName: strings.TrimSpace(profile.DisplayName),
+		Bio:         strings.TrimSpace(profile.Bio),
+		AvatarURL:   strings.TrimSpace(profile.AvatarURL),
+	}
+}
+
+func validateProfile(profile models.Profile) *models.ResponseErr {
+	if n := len(profile.Username); n < MinUsernameLength || n > MaxUsernameLength {
+		return models.NewFieldError("username", fmt.Sprintf("username must be between %d and %d characters", MinUsernameLength, MaxUsernameLength))
+	}
+	if !usernamePattern.MatchString(profile.Username) {
+		return models.NewFieldError("username", "username may only contain letters, digits and underscores")
+	}
+
+	if utf8.RuneCountInString(profile.DisplayName) > MaxDisplayNameLength {
+		return models.NewFieldError("display_name", fmt.Sprintf("display_name must be at most %d characters", MaxDisplayNameLength))
+	}
+	if strings.ContainsFunc(profile.DisplayName, unicode.IsControl) {
+		return models.NewFieldError("display_name", "display_name must not contain control characters")
+	}
+
+	if utf8.RuneCountInString(profile.Bio) > MaxBioLength {
+		return models.NewFieldError("bio", fmt.Sprintf("bio must be at most %d characters", MaxBioLength))
+	}
+
+	if profile.AvatarURL != "" {
+		if len(profile.AvatarURL) > MaxAvatarURLLength {
+			return models.NewFieldError("avatar_url", fmt.Sprintf("avatar_url must be at most %d characters", MaxAvatarURLLength))
+		}
+		parsed, err := url.Parse(profile.AvatarURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return models.NewFieldError("avatar_url", "avatar_url must be an absolute http or https URL")
+		}
+	}
+
+	return nil
+}
+
+// defaultUsername derives a free username from the local part of email for
+// signups that do not pick one, mirroring the backfill in 013_user_profiles.
+func (s *UsersService) defaultUsername(ctx context.Context, email string) (string, *models.ResponseErr) {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	base := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, local)
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	if len(base) < MinUsernameLength {
+		base = "user" + base
+	}
+
+	candidate := base
+	for n := 2; n < 1000; n++ {
+		_, respErr := s.usersRepository.GetByUsername(ctx, candidate)
+		if respErr != nil {
+			if respErr.StatusCode == http.StatusNotFound {
+				return candidate, nil
+			}
+			return "", respErr
+		}
+		candidate = fmt.Sprintf("%s_%d", base, n)
+	}
+
+	return "", &models.ResponseErr{
+		Error:      "Could not pick a username, please choose one",
+		StatusCode: http.StatusConflict,
+	}
+}
+
+func (s *UsersService) GetProfile(ctx context.Context, username string) (*models.PublicProfile, *models.ResponseErr) {
+	user, respErr := s.usersRepository.GetByUsername(ctx, username)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	return &models.PublicProfile{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		Profile:     user.Profile,
+		IsChirpyRed: user.IsChirpyRed,
+	}, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+const MaxReportDetailsLength = 500
+
+// ReportReasons are the reason codes a report can give.
+var ReportReasons = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate":           true,
+	"violence":       true,
+	"sexual_content": true,
+	"misinformation": true,
+	"impersonation":  true,
+	"other":          true,
+}
+
+type ReportsService struct {
+	reportsRepo repositories.ReportsStore
+	chirpRepo   repositories.ChirpsStore
+	usersRepo   repositories.UsersStore
+	revocation  *TokenRevocationService
+}
+
+func NewReportsService(
+	reportsRepo repositories.ReportsStore,
+	chirpRepo repositories.ChirpsStore,
+	usersRepo repositories.UsersStore,
+	revocation *TokenRevocationService,
+) ReportsService {
+	return ReportsService{
+		reportsRepo: reportsRepo,
+		chirpRepo:   chirpRepo,
+		usersRepo:   usersRepo,
+		revocation:  revocation,
+	}
+}
+
+func (s *ReportsService) ReportChirp(ctx context.Context, reporterID, chirpID, reason, details string) (*models.Report, *models.ResponseErr) {
+	chirp, respErr := s.chirpRepo.GetChirpByID(ctx, chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if chirp.DeletedAt != nil {
+		return nil, &models.ResponseErr{
+			Error:      "Cannot report a deleted chirp",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if chirp.UserID.String() == reporterID {
+		return nil, &models.ResponseErr{
+			Error:      "Cannot report your own chirp",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return s.createReport(ctx, reporterID, models.ReportTargetChirp, chirpID, chirp.UserID.String(), reason, details)
+}
+
+func (s *ReportsService) ReportUser(ctx context.Context, reporterID, userID, reason, details string) (*models.Report, *models.ResponseErr) {
+	if _, respErr := s.usersRepo.GetByID(ctx, userID); respErr != nil {
+		return nil, respErr
+	}
+	if userID == reporterID {
+		return nil, &models.ResponseErr{
+			Error:      "Cannot report yourself",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return s.createReport(ctx, reporterID, models.ReportTargetUser, userID, userID, reason, details)
+}
+
+func (s *ReportsService) createReport(ctx context.Context, reporterID, targetType, targetID, targetUserID, reason, details string) (*models.Report, *models.ResponseErr) {
+	if !ReportReasons[reason] {
+		return nil, models.NewFieldError("reason", fmt.Sprintf("unknown reason %q", reason))
+	}
+	if utf8.RuneCountInString(details) > MaxReportDetailsLength {
+		return nil, models.NewFieldError("details", fmt.Sprintf("details must be at most %d characters", MaxReportDetailsLength))
+	}
+
+	return s.reportsRepo.CreateReport(ctx, reporterID, targetType, targetID, targetUserID, reason, details)
+}
+
+// ListReports pages through the moderation queue oldest first. An empty
+// status lists every report.
+func (s *ReportsService) ListReports(ctx context.Context, status, cursor string, limit int) (*models.ReportPage, *models.ResponseErr) {
+	switch status {
+	case "", models.ReportStatusOpen, models.ReportStatusClaimed, models.ReportStatusResolved:
+	default:
+		return nil, models.NewFieldError("status", "status must be open, claimed or resolved")
+	}
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	reports, respErr := s.reportsRepo.ListReports(ctx, status, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := models.ReportPage{
+		Reports: make([]models.Report, 0, limit),
+	}
+	results := *reports
+	if len(results) > limit {
+		results = results[:limit]
+		last := results[len(results)-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	page.Reports = append(page.Reports, results...)
+
+	return &page, nil
+}
+
+// GetReport returns a report with its decision history.
+func (s *ReportsService) GetReport(ctx context.Context, reportID string) (*models.Report, *models.ResponseErr) {
+	report, respErr := s.reportsRepo.GetReport(ctx, reportID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	decisions, respErr := s.reportsRepo.GetDecisions(ctx, reportID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	report.Decisions = *decisions
+
+	return report, nil
+}
+
+func (s *ReportsService) Claim(ctx context.Context, moderatorID, reportID string) (*models.Report, *models.ResponseErr) {
+	return s.reportsRepo.ClaimReport(ctx, reportID, moderatorID)
+}
+
+// Resolve closes a report with one of the resolutions. hide_chirp hides the
+// reported chirp, suspend_user suspends the reported user or the author of
+// the reported chirp and signs them out everywhere. The report must be open
+// or claimed by moderatorID. The store applies the resolution in the same
+// transaction that records it, so a report that another moderator claims or
+// resolves first is left untouched.
+func (s *ReportsService) Resolve(ctx context.Context, moderatorID, reportID, resolution, note string) (*models.Report, *models.ResponseErr) {
+	report, respErr := s.reportsRepo.GetReport(ctx, reportID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if respErr := checkMayResolve(report, moderatorID); respErr != nil {
+		return nil, respErr
+	}
+
+	switch resolution {
+	case models.ResolutionDismiss, models.ResolutionSuspendUser:
+	case models.ResolutionHideChirp:
+		if report.TargetType != models.ReportTargetChirp {
+			return nil, &models.ResponseErr{
+				Error:      "hide_chirp only applies to chirp reports",
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+	default:
+		return nil, models.NewFieldError("action", "action must be hide_chirp, suspend_user or dismiss")
+	}
+
+	resolved, respErr := s.reportsRepo.ResolveReport(ctx, reportID, moderatorID, resolution, note)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if resolution == models.ResolutionSuspendUser {
+		s.signOutQuietly(ctx, resolved.TargetUserID.String())
+	}
+
+	return resolved, nil
+}
+
+// signOutQuietly runs after a suspension has been stored. Suspended users
+// are already refused at login, on refresh and when posting, so a failure
+// here is logged rather than reported as a failed resolution.
+func (s *ReportsService) signOutQuietly(ctx context.Context, userID string) {
+	if respErr := s.revocation.SignOutEverywhere(ctx, userID); respErr != nil {
+		log.Printf("Could not sign out suspended user %s: %s", userID, respErr.Error)
+	}
+}
+
+// checkMayResolve catches decisions the store would refuse before any
+// action is applied. The store checks again when the report is resolved.
+func checkMayResolve(report *models.Report, moderatorID string) *models.ResponseErr {
+	if report.Status == models.ReportStatusResolved {
+		return &models.ResponseErr{
+			Error:      "Report already resolved",
+			StatusCode: http.StatusConflict,
+		}
+	}
+	if report.ClaimedBy != nil && report.ClaimedBy.String() != moderatorID {
+		return &models.ResponseErr{
+			Error:      "Report claimed by another moderator",
+			StatusCode: http.StatusConflict,
+		}
+	}
+	return nil
+}
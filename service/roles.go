@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// GrantRole gives the user an extra role. The user is signed out
+// everywhere, like on every role change, so no token carries stale roles.
+func (s *UsersService) GrantRole(ctx context.Context, userID, role string) (*models.UserRoles, *models.ResponseErr) {
+	if respErr := checkRole(role); respErr != nil {
+		return nil, respErr
+	}
+
+	user, respErr := s.usersRepository.GrantRole(ctx, userID, role)
+	if respErr != nil {
+		return nil, respErr
+	}
+	return s.rolesChanged(ctx, user)
+}
+
+// RevokeRole takes a role away from the user and ends their sessions, so
+// the role stops working right away. Admins cannot drop their own admin
+// role so the last admin cannot lock everyone out.
+func (s *UsersService) RevokeRole(ctx context.Context, actorID, userID, role string) (*models.UserRoles, *models.ResponseErr) {
+	if respErr := checkRole(role); respErr != nil {
+		return nil, respErr
+	}
+	if role == models.RoleUser {
+		return nil, &models.ResponseErr{
+			Error:      "Every account has the user role",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if role == models.RoleAdmin && actorID == userID {
+		return nil, &models.ResponseErr{
+			Error:      "Cannot revoke your own admin role",
+			StatusCode: http.StatusConflict,
+		}
+	}
+
+	user, respErr := s.usersRepository.RevokeRole(ctx, userID, role)
+	if respErr != nil {
+		return nil, respErr
+	}
+	return s.rolesChanged(ctx, user)
+}
+
+func (s *UsersService) GetRoles(ctx context.Context, userID string) (*models.UserRoles, *models.ResponseErr) {
+	return userRoles(s.usersRepository.GetByID(ctx, userID))
+}
+
+// rolesChanged signs the user out everywhere after a role change. Roles
+// are baked into access tokens and would otherwise keep working until the
+// tokens expire.
+func (s *UsersService) rolesChanged(ctx context.Context, user *models.User) (*models.UserRoles, *models.ResponseErr) {
+	if respErr := s.revocation.SignOutEverywhere(ctx, user.ID.String()); respErr != nil {
+		return nil, respErr
+	}
+	return userRoles(user, nil)
+}
+
+func userRoles(user *models.User, respErr *models.ResponseErr) (*models.UserRoles, *models.ResponseErr) {
+	if respErr != nil {
+		return nil, respErr
+	}
+	return &models.UserRoles{
+		UserID: user.ID,
+		Roles:  user.Roles,
+	}, nil
+}
+
+func checkRole(role string) *models.ResponseErr {
+	if !auth.IsRole(role) {
+		return models.NewFieldError("role", "role must be user, moderator or admin")
+	}
+	return nil
+}
+
+// bootstrapAdmin grants the admin role to accounts listed in ADMIN_EMAILS
+// when they log in, so a fresh deployment can get its first admin.
+func (s *UsersService) bootstrapAdmin(ctx context.Context, user *models.User) *models.ResponseErr {
+	if slices.Contains(user.Roles, models.RoleAdmin) {
+		return nil
+	}
+	if !slices.ContainsFunc(s.appState.AdminEmails, func(email string) bool {
+		return strings.EqualFold(email, user.Email)
+	}) {
+		return nil
+	}
+
+	updated, respErr := s.usersRepository.GrantRole(ctx, user.ID.String(), models.RoleAdmin)
+	if respErr != nil {
+		return respErr
+	}
+	user.Roles = updated.Roles
+	return nil
+}
@@ -1,47 +1,44 @@
 package service
 
 import (
-	"net/http"
-	"strings"
-
+	"github.com/karaMuha/go-chirpy/internal/moderation"
 	"github.com/karaMuha/go-chirpy/models"
 )
 
 type Service struct {
-	profane map[string]string
+	moderator moderation.Moderator
 }
 
-func NewService() Service {
-	profane := make(map[string]string)
-	profane["kerfuffle"] = "kerfuffle"
-	profane["sharbert"] = "sharbert"
-	profane["fornax"] = "fornax"
-
+func NewService(moderator moderation.Moderator) Service {
 	return Service{
-		profane: profane,
+		moderator: moderator,
 	}
 }
 
+// DefaultWordList is used when no word list file is configured. Its words
+// trigger action.
+func DefaultWordList(action moderation.Action) *moderation.WordList {
+	return moderation.NewWordList(map[string]moderation.Action{
+		"kerfuffle": action,
+		"sharbert":  action,
+		"fornax":    action,
+	})
+}
+
 type Response struct {
 	CleanedBody string `json:"cleaned_body,omitempty"`
 }
 
 func (s *Service) ValidateChirp(chirp models.Chirp) (*Response, *models.ResponseErr) {
 	if len(chirp.Body) > 140 {
-		respErr := models.ResponseErr{
-			Error:      "Chirp is too long",
-			StatusCode: http.StatusBadRequest,
-		}
-		return nil, &respErr
+		return nil, models.NewFieldError("body", "Chirp is too long")
 	}
 
-	words := strings.Fields(chirp.Body)
-	for i, word := range words {
-		if _, ok := s.profane[strings.ToLower(word)]; ok {
-			words[i] = "****"
-		}
+	verdict := s.moderator.Moderate(chirp.Body)
+	if verdict.Action == moderation.Reject {
+		return nil, models.NewFieldError("body", "Chirp rejected: "+verdict.Reason)
 	}
 
-	response := Response{CleanedBody: strings.Join(words, " ")}
+	response := Response{CleanedBody: verdict.Body}
 	return &response, nil
 }
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// ListSessions returns the user's live sessions, marking the one
+// currentSessionID belongs to.
+func (s *UsersService) ListSessions(ctx context.Context, userID string, currentSessionID uuid.UUID) ([]models.Session, *models.ResponseErr) {
+	sessions, respErr := s.refreshTokenRepo.ListSessions(ctx, userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the user out on one device, including the access
+// tokens already issued to it.
+func (s *UsersService) RevokeSession(ctx context.Context, userID, sessionID string) *models.ResponseErr {
+	parsedSessionID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return &models.ResponseErr{
+			Error:      "Session not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	if respErr := s.refreshTokenRepo.RevokeSession(ctx, userID, sessionID); respErr != nil {
+		return respErr
+	}
+	return s.revocation.DenySessions(ctx, parsedSessionID)
+}
+
+// RevokeAllSessions signs the user out everywhere, including the session
+// making the request.
+func (s *UsersService) RevokeAllSessions(ctx context.Context, userID string) *models.ResponseErr {
+	return s.revocation.SignOutEverywhere(ctx, userID)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+const (
+	DefaultSigningAlgorithm = auth.AlgEdDSA
+	// DefaultKeyRetention is how long a rotated out key keeps verifying
+	// tokens. It has to outlast the longest lived access token.
+	DefaultKeyRetention = 24 * time.Hour
+	// keyRotationCheckInterval is how often RunRotation looks for keys
+	// rotated by other instances and for a signing key that is due.
+	keyRotationCheckInterval = time.Minute
+)
+
+// SigningKeysService manages the keys access tokens are signed with and
+// loads them into the app's keyring.
+type SigningKeysService struct {
+	keysRepo    repositories.SigningKeysStore
+	keyring     *auth.Keyring
+	algorithm   string
+	rotateEvery time.Duration
+	retention   time.Duration
+}
+
+// NewSigningKeysService returns a service that signs with algorithm and
+// rotates keys every rotateEvery, or only on command when it is zero.
+func NewSigningKeysService(
+	keysRepo repositories.SigningKeysStore,
+	keyring *auth.Keyring,
+	algorithm string,
+	rotateEvery time.Duration,
+	retention time.Duration,
+) SigningKeysService {
+	if algorithm == "" {
+		algorithm = DefaultSigningAlgorithm
+	}
+	if retention == 0 {
+		retention = DefaultKeyRetention
+	}
+	return SigningKeysService{
+		keysRepo:    keysRepo,
+		keyring:     keyring,
+		algorithm:   algorithm,
+		rotateEvery: rotateEvery,
+		retention:   retention,
+	}
+}
+
+// Load fills the keyring from the store. A first key is created when there
+// is none, and the keys are rotated when the signing key uses another
+// algorithm than configured.
+func (s *SigningKeysService) Load(ctx context.Context) *models.ResponseErr {
+	if respErr := s.reload(ctx); respErr != nil {
+		return respErr
+	}
+	s.keyring.SetSource(func() ([]models.SigningKey, error) {
+		keys, respErr := s.keysRepo.ListSigningKeys(context.Background())
+		if respErr != nil {
+			return nil, errors.New(respErr.Error)
+		}
+		return keys, nil
+	})
+
+	signing, ok := s.keyring.SigningKey()
+	if !ok || signing.Algorithm != s.algorithm {
+		_, respErr := s.Rotate(ctx)
+		return respErr
+	}
+	return nil
+}
+
+// Rotate makes a new key the signing key. The previous keys are retired,
+// they keep verifying tokens for the retention period and are deleted
+// after that.
+func (s *SigningKeysService) Rotate(ctx context.Context) (*models.SigningKey, *models.ResponseErr) {
+	key, err := auth.GenerateSigningKey(s.algorithm)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	if respErr := s.keysRepo.CreateSigningKey(ctx, key); respErr != nil {
+		return nil, respErr
+	}
+	now := time.Now().UTC()
+	if respErr := s.keysRepo.RetireSigningKeys(ctx, key.CreatedAt, now); respErr != nil {
+		return nil, respErr
+	}
+	if respErr := s.keysRepo.DeleteSigningKeys(ctx, now.Add(-s.retention)); respErr != nil {
+		return nil, respErr
+	}
+	if respErr := s.reload(ctx); respErr != nil {
+		return nil, respErr
+	}
+
+	log.Printf("Rotated token signing key, new key %s (%s)", key.ID, key.Algorithm)
+	return &key, nil
+}
+
+// RunRotation picks up keys rotated by other instances and rotates the
+// signing key once it is older than the rotation interval, until ctx is
+// done.
+func (s *SigningKeysService) RunRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if respErr := s.reload(ctx); respErr != nil {
+			log.Printf("Could not reload signing keys: %s", respErr.Error)
+			continue
+		}
+		signing, ok := s.keyring.SigningKey()
+		if s.rotateEvery == 0 || (ok && time.Since(signing.CreatedAt) < s.rotateEvery) {
+			continue
+		}
+		if _, respErr := s.Rotate(ctx); respErr != nil {
+			log.Printf("Could not rotate signing key: %s", respErr.Error)
+		}
+	}
+}
+
+func (s *SigningKeysService) reload(ctx context.Context) *models.ResponseErr {
+	keys, respErr := s.keysRepo.ListSigningKeys(ctx)
+	if respErr != nil {
+		return respErr
+	}
+	if err := s.keyring.SetKeys(keys); err != nil {
+		return &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	return nil
+}
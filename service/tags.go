@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/entities"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+const (
+	DefaultTrendingWindow = 24 * time.Hour
+	MaxTrendingWindow     = 7 * 24 * time.Hour
+)
+
+// saveEntities extracts hashtags and mentions from a new chirp and stores
+// them. Mentions of unknown users are ignored.
+func (s *ChirpsService) saveEntities(ctx context.Context, chirp *models.Chirp) *models.ResponseErr {
+	tags := entities.ExtractHashtags(chirp.Body)
+	if respErr := s.tagsRepo.SaveHashtags(ctx, chirp.ID.String(), tags, chirp.CreatedAt); respErr != nil {
+		return respErr
+	}
+
+	var userIDs []string
+	for _, mention := range entities.ExtractMentions(chirp.Body) {
+		user, respErr := s.resolveMention(ctx, mention)
+		if respErr != nil {
+			if respErr.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return respErr
+		}
+		userIDs = append(userIDs, user.ID.String())
+	}
+
+	return s.tagsRepo.SaveMentions(ctx, chirp.ID.String(), userIDs, chirp.CreatedAt)
+}
+
+// saveEntitiesQuietly runs once the chirp itself has been stored. Hashtags
+// and mentions only feed search and notifications, so a failure is logged
+// rather than reported for a write that already succeeded.
+func (s *ChirpsService) saveEntitiesQuietly(ctx context.Context, chirp *models.Chirp) {
+	if respErr := s.saveEntities(ctx, chirp); respErr != nil {
+		log.Printf("Could not save hashtags and mentions of chirp %s: %s", chirp.ID, respErr.Error)
+	}
+}
+
+// resolveMention looks up the user behind an @mention, which is either an
+// email address, matched the way addresses are stored, or a username.
+func (s *ChirpsService) resolveMention(ctx context.Context, mention string) (*models.User, *models.ResponseErr) {
+	if strings.Contains(mention, "@") {
+		return s.usersRepo.GetByEmail(ctx, normalizeEmail(mention))
+	}
+
+	return s.usersRepo.GetByUsername(ctx, mention)
+}
+
+func (s *ChirpsService) GetByHashtag(ctx context.Context, viewerID, tag, cursor string, limit int) (*models.ChirpPage, *models.ResponseErr) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	chirps, respErr := s.chripRepo.GetByHashtag(ctx, tag, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := newChirpPage(*chirps, limit)
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, page.Chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return page, nil
+}
+
+func (s *ChirpsService) GetMentions(ctx context.Context, viewerID, userID, cursor string, limit int) (*models.ChirpPage, *models.ResponseErr) {
+	if _, respErr := s.usersRepo.GetByID(ctx, userID); respErr != nil {
+		return nil, respErr
+	}
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	chirps, respErr := s.chripRepo.GetMentioning(ctx, userID, after, limit+1)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	page := newChirpPage(*chirps, limit)
+	if respErr := decorateChirps(ctx, s.chripRepo, s.likesRepo, viewerID, page.Chirps); respErr != nil {
+		return nil, respErr
+	}
+
+	return page, nil
+}
+
+// TrendingHashtags returns the most used hashtags over the last window.
+func (s *ChirpsService) TrendingHashtags(ctx context.Context, window time.Duration, limit int) (*models.TrendingHashtags, *models.ResponseErr) {
+	hashtags, respErr := s.tagsRepo.GetTrendingHashtags(ctx, time.Now().UTC().Add(-window), limit)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	trending := models.TrendingHashtags{
+		Window:   window.String(),
+		Hashtags: make([]models.TrendingHashtag, 0, len(*hashtags)),
+	}
+	trending.Hashtags = append(trending.Hashtags, *hashtags...)
+
+	return &trending, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/karaMuha/go-chirpy/sql/repositories"
+)
+
+const (
+	// deniedTokensSyncInterval is how long a token revoked on another
+	// instance may still be accepted here.
+	deniedTokensSyncInterval = 5 * time.Second
+	// deniedTokensSyncOverlap re-reads entries written shortly before the
+	// last sync, covering clock skew between instances and slow writes.
+	deniedTokensSyncOverlap = time.Minute
+	// deniedTokensCleanupInterval is how often expired entries are deleted
+	// from the store.
+	deniedTokensCleanupInterval = 10 * time.Minute
+)
+
+// TokenRevocationService ends sessions at once: their refresh tokens are
+// revoked and the access tokens already issued to them are put on the
+// app's denylist until they would have expired.
+type TokenRevocationService struct {
+	deniedTokensRepo repositories.DeniedTokensStore
+	refreshTokenRepo repositories.RefreshTokenStore
+	denylist         *auth.Denylist
+}
+
+func NewTokenRevocationService(
+	deniedTokensRepo repositories.DeniedTokensStore,
+	refreshTokenRepo repositories.RefreshTokenStore,
+	denylist *auth.Denylist,
+) TokenRevocationService {
+	return TokenRevocationService{
+		deniedTokensRepo: deniedTokensRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		denylist:         denylist,
+	}
+}
+
+// SignOutEverywhere ends every session of the user.
+func (s *TokenRevocationService) SignOutEverywhere(ctx context.Context, userID string) *models.ResponseErr {
+	sessionIDs, respErr := s.refreshTokenRepo.RevokeAllForUser(ctx, userID)
+	if respErr != nil {
+		return respErr
+	}
+	return s.DenySessions(ctx, sessionIDs...)
+}
+
+// SignOutOtherSessions ends every session of the user but keepSessionID.
+func (s *TokenRevocationService) SignOutOtherSessions(ctx context.Context, userID, keepSessionID string) *models.ResponseErr {
+	sessionIDs, respErr := s.refreshTokenRepo.RevokeOtherSessions(ctx, userID, keepSessionID)
+	if respErr != nil {
+		return respErr
+	}
+	return s.DenySessions(ctx, sessionIDs...)
+}
+
+// DenySessions rejects the access tokens issued to sessionIDs. Their
+// refresh tokens have to be revoked separately. Tokens are denied here
+// first, so this instance rejects them even if the store fails.
+func (s *TokenRevocationService) DenySessions(ctx context.Context, sessionIDs ...uuid.UUID) *models.ResponseErr {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	// No access token issued to the sessions outlives this.
+	expiresAt := time.Now().Add(AccessTokenTTL)
+	ids := make([]string, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		ids[i] = sessionID.String()
+		s.denylist.Add(ids[i], expiresAt)
+	}
+
+	return s.deniedTokensRepo.DenyTokens(ctx, ids, expiresAt)
+}
+
+// Load fills the denylist with every entry in the store that has not
+// expired.
+func (s *TokenRevocationService) Load(ctx context.Context) *models.ResponseErr {
+	_, respErr := s.sync(ctx, time.Time{})
+	return respErr
+}
+
+// RunSync copies tokens denied by other instances into the denylist and
+// drops expired entries, until ctx is done.
+func (s *TokenRevocationService) RunSync(ctx context.Context) {
+	ticker := time.NewTicker(deniedTokensSyncInterval)
+	defer ticker.Stop()
+
+	lastSync := time.Now()
+	lastCleanup := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		synced, respErr := s.sync(ctx, lastSync.Add(-deniedTokensSyncOverlap))
+		if respErr != nil {
+			log.Printf("Could not sync denied tokens: %s", respErr.Error)
+			continue
+		}
+		lastSync = synced
+
+		now := time.Now()
+		s.denylist.Prune(now)
+		if now.Sub(lastCleanup) >= deniedTokensCleanupInterval {
+			if respErr := s.deniedTokensRepo.DeleteExpiredDeniedTokens(ctx); respErr != nil {
+				log.Printf("Could not delete expired denied tokens: %s", respErr.Error)
+			}
+			lastCleanup = now
+		}
+	}
+}
+
+// sync adds the entries created since the given time and returns when the
+// read started, which is where the next sync picks up.
+func (s *TokenRevocationService) sync(ctx context.Context, since time.Time) (time.Time, *models.ResponseErr) {
+	started := time.Now()
+	tokens, respErr := s.deniedTokensRepo.ListDeniedTokens(ctx, since)
+	if respErr != nil {
+		return time.Time{}, respErr
+	}
+	for _, token := range tokens {
+		s.denylist.Add(token.ID, token.ExpiresAt)
+	}
+	return started, nil
+}
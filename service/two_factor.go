@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
+	"github.com/karaMuha/go-chirpy/internal/totp"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+const (
+	TOTPIssuer        = "Chirpy"
+	MFATokenTTL       = 5 * time.Minute
+	RecoveryCodeCount = 10
+)
+
+// recoveryCodeAlphabet leaves out characters that are easy to mix up.
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// EnrollTOTP starts 2FA setup with a new secret. Nothing changes for login
+// until ConfirmTOTP succeeds, and enrolling again replaces the secret.
+func (s *UsersService) EnrollTOTP(ctx context.Context, userID string) (*models.TOTPEnrollment, *models.ResponseErr) {
+	user, respErr := s.usersRepository.GetByID(ctx, userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	if respErr := s.twoFactorRepo.SaveTOTPSecret(ctx, userID, secret); respErr != nil {
+		return nil, respErr
+	}
+
+	return &models.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables 2FA once the user proves their app produces valid
+// codes, and returns recovery codes that are never shown again.
+func (s *UsersService) ConfirmTOTP(ctx context.Context, userID, code string) (*models.RecoveryCodes, *models.ResponseErr) {
+	enrollment, respErr := s.twoFactorRepo.GetTOTP(ctx, userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if enrollment.EnabledAt != nil {
+		return nil, &models.ResponseErr{
+			Error:      "Two-factor authentication is already enabled",
+			StatusCode: http.StatusConflict,
+		}
+	}
+
+	step, ok := totp.Validate(enrollment.Secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, errInvalidCode()
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	if respErr := s.twoFactorRepo.EnableTOTP(ctx, userID, step, hashes); respErr != nil {
+		return nil, respErr
+	}
+
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTOTP turns 2FA off after checking a current code or recovery code.
+func (s *UsersService) DisableTOTP(ctx context.Context, userID, code string) *models.ResponseErr {
+	if respErr := s.verifySecondFactor(ctx, userID, code); respErr != nil {
+		return respErr
+	}
+
+	return s.twoFactorRepo.DisableTOTP(ctx, userID)
+}
+
+// CompleteMFALogin exchanges the token from an MFA challenge and a code
+// from the user's app, or a recovery code, for the tokens Login would have
+// issued.
+func (s *UsersService) CompleteMFALogin(ctx context.Context, mfaToken, code string, client models.ClientInfo) (*models.User, *models.ResponseErr) {
+	userID, err := auth.ParseMFAToken(mfaToken, s.appState.Keys)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusUnauthorized,
+			Code:       models.CodeInvalidToken,
+		}
+	}
+
+	user, respErr := s.usersRepository.GetByID(ctx, userID.String())
+	if respErr != nil {
+		return nil, respErr
+	}
+	if user.SuspendedAt != nil {
+		return nil, errSuspended()
+	}
+
+	// Codes are guessed against the same per-account count as passwords.
+	if respErr := s.throttle.Check(ctx, user.Email, user, client); respErr != nil {
+		return nil, respErr
+	}
+	if respErr := s.verifySecondFactor(ctx, user.ID.String(), code); respErr != nil {
+		if respErr.Code == models.CodeInvalidMFACode {
+			if failErr := s.throttle.Fail(ctx, user.Email, user, models.LoginFailureWrongMFACode, client); failErr != nil {
+				return nil, failErr
+			}
+		}
+		return nil, respErr
+	}
+	if respErr := s.throttle.Succeed(ctx, user.Email); respErr != nil {
+		return nil, respErr
+	}
+
+	if respErr := s.issueTokens(ctx, user, AccessTokenTTL, client); respErr != nil {
+		return nil, respErr
+	}
+	return user, nil
+}
+
+func (s *UsersService) twoFactorEnabled(ctx context.Context, userID string) (bool, *models.ResponseErr) {
+	enrollment, respErr := s.twoFactorRepo.GetTOTP(ctx, userID)
+	if respErr != nil {
+		if respErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, respErr
+	}
+	return enrollment.EnabledAt != nil, nil
+}
+
+func (s *UsersService) mfaChallenge(user *models.User) (*models.MFAChallenge, *models.ResponseErr) {
+	token, err := auth.MakeMFAToken(user.ID, s.appState.Keys, MFATokenTTL)
+	if err != nil {
+		return nil, &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	return &models.MFAChallenge{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresAt:   time.Now().UTC().Add(MFATokenTTL),
+	}, nil
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code. Each
+// code works only once.
+func (s *UsersService) verifySecondFactor(ctx context.Context, userID, code string) *models.ResponseErr {
+	enrollment, respErr := s.twoFactorRepo.GetTOTP(ctx, userID)
+	if respErr != nil {
+		return respErr
+	}
+	if enrollment.EnabledAt == nil {
+		return &models.ResponseErr{
+			Error:      "Two-factor authentication is not enabled",
+			StatusCode: http.StatusConflict,
+		}
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(enrollment.Secret, code, time.Now())
+		if !ok {
+			return errInvalidCode()
+		}
+		return s.twoFactorRepo.UseTOTPStep(ctx, userID, step)
+	}
+
+	return s.twoFactorRepo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+}
+
+// generateRecoveryCodes returns codes formatted like "abcd-efgh-jkmn-pqrs"
+// along with the hashes to store. Each code carries 16 characters from a 31
+// letter alphabet, about 79 bits.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		var code strings.Builder
+		for j := 0; j < 16; {
+			var b [1]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				return nil, nil, err
+			}
+			// Skip bytes past the last full multiple of the alphabet size
+			// so every character is equally likely.
+			if int(b[0]) >= 256-256%len(recoveryCodeAlphabet) {
+				continue
+			}
+			if j > 0 && j%4 == 0 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(recoveryCodeAlphabet[int(b[0])%len(recoveryCodeAlphabet)])
+			j++
+		}
+		codes[i] = code.String()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed
+// loosely.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return auth.HashToken(code)
+}
+
+func errInvalidCode() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Invalid code",
+		StatusCode: http.StatusUnauthorized,
+		Code:       models.CodeInvalidMFACode,
+	}
+}
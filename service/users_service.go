@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"time"
 
@@ -11,122 +12,324 @@ import (
 	"github.com/karaMuha/go-chirpy/state"
 )
 
+// RefreshTokenTTL is how long a refresh token stays valid when unused. Each
+// refresh replaces it with a new one.
+const RefreshTokenTTL = 60 * 24 * time.Hour
+
+// AccessTokenTTL is how long access tokens issued by a login or refresh
+// stay valid.
+const AccessTokenTTL = time.Hour
+
 type UsersService struct {
-	usersRepository  repositories.UsersRepository
+	usersRepository  repositories.UsersStore
 	appState         *state.AppState
-	refreshTokenRepo repositories.RefreshTokenRepository
+	refreshTokenRepo repositories.RefreshTokenStore
+	verification     *EmailVerificationService
+	twoFactorRepo    repositories.TwoFactorStore
+	revocation       *TokenRevocationService
+	throttle         *LoginThrottleService
+	passwords        *PasswordPolicyService
 }
 
 func NewUsersService(
-	usersRepository repositories.UsersRepository,
+	usersRepository repositories.UsersStore,
 	appState *state.AppState,
-	refreshTokenRepo repositories.RefreshTokenRepository,
+	refreshTokenRepo repositories.RefreshTokenStore,
+	verification *EmailVerificationService,
+	twoFactorRepo repositories.TwoFactorStore,
+	revocation *TokenRevocationService,
+	throttle *LoginThrottleService,
+	passwords *PasswordPolicyService,
 ) UsersService {
 	return UsersService{
 		usersRepository:  usersRepository,
 		appState:         appState,
 		refreshTokenRepo: refreshTokenRepo,
+		verification:     verification,
+		twoFactorRepo:    twoFactorRepo,
+		revocation:       revocation,
+		throttle:         throttle,
+		passwords:        passwords,
 	}
 }
 
-func (s *UsersService) CreateUser(ctx context.Context, email, password string) (*models.User, *models.ResponseErr) {
-	return s.usersRepository.CreateUser(ctx, email, password)
+// CreateUser registers an account and mails a link to verify its email.
+func (s *UsersService) CreateUser(ctx context.Context, email, password string, profile models.Profile) (*models.User, *models.ResponseErr) {
+	email = normalizeEmail(email)
+	if respErr := validateEmail(email); respErr != nil {
+		return nil, respErr
+	}
+
+	profile = normalizeProfile(profile)
+	if profile.Username == "" {
+		username, respErr := s.defaultUsername(ctx, email)
+		if respErr != nil {
+			return nil, respErr
+		}
+		profile.Username = username
+	}
+	if respErr := validateProfile(profile); respErr != nil {
+		return nil, respErr
+	}
+	hashedPassword, respErr := s.passwords.Hash(password, email)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	user, respErr := s.usersRepository.CreateUser(ctx, email, hashedPassword, profile)
+	if respErr != nil {
+		return nil, respErr
+	}
+	s.verification.sendVerificationQuietly(ctx, user)
+
+	return user, nil
 }
 
 func (s *UsersService) ResetUsers(ctx context.Context) *models.ResponseErr {
 	return s.usersRepository.ResetTable(ctx)
 }
 
-func (s *UsersService) Login(ctx context.Context, email, password string, expirationDuration int) (*models.User, *models.ResponseErr) {
+// Login checks the password. Accounts with 2FA get an MFA challenge to
+// complete with CompleteMFALogin, all others get their tokens right away.
+func (s *UsersService) Login(ctx context.Context, email, password string, expirationDuration int, client models.ClientInfo) (*models.User, *models.MFAChallenge, *models.ResponseErr) {
+	email = normalizeEmail(email)
 	user, respErr := s.usersRepository.GetByEmail(ctx, email)
 	if respErr != nil {
-		return nil, respErr
+		if respErr.StatusCode != http.StatusNotFound {
+			return nil, nil, respErr
+		}
+		user = nil
 	}
 
-	if err := auth.CheckPassword(password, user.Password); err != nil {
-		return nil, &models.ResponseErr{
-			Error:      "incorrect email or password",
-			StatusCode: http.StatusUnauthorized,
+	if respErr := s.throttle.Check(ctx, email, user, client); respErr != nil {
+		return nil, nil, respErr
+	}
+
+	// Unknown emails fail the same way and after the same bcrypt work as
+	// wrong passwords, so logins cannot be used to find accounts.
+	reason := models.LoginFailureWrongPassword
+	hash := dummyPasswordHash()
+	if user != nil {
+		hash = user.Password
+	} else {
+		reason = models.LoginFailureUnknownEmail
+	}
+	if err := auth.CheckPassword(password, hash); err != nil || user == nil {
+		if respErr := s.throttle.Fail(ctx, email, user, reason, client); respErr != nil {
+			return nil, nil, respErr
 		}
+		return nil, nil, errLoginFailed()
 	}
 
-	token, err := auth.MakeJWT(user.ID, s.appState.Secret, time.Duration(expirationDuration))
+	if respErr := s.throttle.Succeed(ctx, email); respErr != nil {
+		return nil, nil, respErr
+	}
+	s.rehashQuietly(ctx, user, password)
+	return s.finishLogin(ctx, user, time.Duration(expirationDuration)*time.Second, client)
+}
+
+// rehashQuietly replaces the stored hash of user's password when it was
+// made with a lower bcrypt cost than new hashes get. A failure only means
+// trying again on the next login.
+func (s *UsersService) rehashQuietly(ctx context.Context, user *models.User, password string) {
+	if !auth.NeedsRehash(user.Password) {
+		return
+	}
+
+	userID := user.ID.String()
+	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
-		}
+		log.Printf("Could not rehash password of user %s: %v", userID, err)
+		return
+	}
+	if respErr := s.usersRepository.UpdatePassword(ctx, userID, hashedPassword); respErr != nil {
+		log.Printf("Could not rehash password of user %s: %s", userID, respErr.Error)
+		return
+	}
+	user.Password = hashedPassword
+}
+
+// finishLogin is shared by all ways of signing in once the user is known:
+// it returns an MFA challenge when 2FA is enabled, tokens otherwise.
+func (s *UsersService) finishLogin(ctx context.Context, user *models.User, expiresIn time.Duration, client models.ClientInfo) (*models.User, *models.MFAChallenge, *models.ResponseErr) {
+	if user.SuspendedAt != nil {
+		return nil, nil, errSuspended()
+	}
+	if respErr := s.bootstrapAdmin(ctx, user); respErr != nil {
+		return nil, nil, respErr
 	}
-	user.Token = token
 
+	enabled, respErr := s.twoFactorEnabled(ctx, user.ID.String())
+	if respErr != nil {
+		return nil, nil, respErr
+	}
+	if enabled {
+		challenge, respErr := s.mfaChallenge(user)
+		return nil, challenge, respErr
+	}
+
+	if respErr := s.issueTokens(ctx, user, expiresIn, client); respErr != nil {
+		return nil, nil, respErr
+	}
+	return user, nil, nil
+}
+
+// issueTokens starts a new session for client and sets its access token and
+// refresh token on user.
+func (s *UsersService) issueTokens(ctx context.Context, user *models.User, expiresIn time.Duration, client models.ClientInfo) *models.ResponseErr {
 	refreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		return nil, &models.ResponseErr{
+		return &models.ResponseErr{
 			Error:      err.Error(),
 			StatusCode: http.StatusInternalServerError,
 		}
 	}
-	respErr = s.refreshTokenRepo.SaveRefreshToken(ctx, refreshToken, user.ID.String(), time.Now().Add(60*24*time.Hour))
+	session, respErr := s.refreshTokenRepo.SaveRefreshToken(ctx, refreshToken, user.ID.String(), time.Now().Add(RefreshTokenTTL), client)
 	if respErr != nil {
-		return nil, respErr
+		return respErr
 	}
 	user.RefreshToken = refreshToken
 
-	return user, nil
+	token, err := auth.MakeUserJWT(user, session.FamilyID, s.appState.Keys, expiresIn)
+	if err != nil {
+		return &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	user.Token = token
+
+	return nil
 }
 
-func (s *UsersService) UpdateAccount(ctx context.Context, userID, email, password string) (*models.User, *models.ResponseErr) {
+// UpdateAccount changes the caller's credentials and profile. An empty email
+// or password keeps the current one. A new email has to be verified again,
+// a new password signs out every session but sessionID.
+func (s *UsersService) UpdateAccount(ctx context.Context, userID, sessionID, email, password string, update ProfileUpdate) (*models.User, *models.ResponseErr) {
 	user, respErr := s.usersRepository.GetByID(ctx, userID)
 	if respErr != nil {
 		return nil, respErr
 	}
-	hashedPassword, err := auth.HashPassword(password)
-	if err != nil {
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+
+	email = normalizeEmail(email)
+	emailChanged := email != "" && email != user.Email
+	if emailChanged {
+		if respErr := validateEmail(email); respErr != nil {
+			return nil, respErr
+		}
+		user.Email = email
+	}
+	if password != "" {
+		hashedPassword, respErr := s.passwords.Hash(password, user.Email)
+		if respErr != nil {
+			return nil, respErr
 		}
+		user.Password = hashedPassword
 	}
 
-	user.Email = email
-	user.Password = hashedPassword
+	profile := normalizeProfile(update.apply(user.Profile))
+	if respErr := validateProfile(profile); respErr != nil {
+		return nil, respErr
+	}
+
+	updatedUser, respErr := s.usersRepository.UpdateAccount(ctx, userID, user.Email, user.Password, profile)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if password != "" {
+		if respErr := s.revocation.SignOutOtherSessions(ctx, userID, sessionID); respErr != nil {
+			return nil, respErr
+		}
+	}
+	if emailChanged {
+		s.verification.sendVerificationQuietly(ctx, updatedUser)
+	}
 
-	updatedUser, respErr := s.usersRepository.UpdateAccount(ctx, userID, email, hashedPassword)
-	return updatedUser, respErr
+	return updatedUser, nil
 }
 
 func (s *UsersService) UpgradeToRed(ctx context.Context, userID string) *models.ResponseErr {
 	return s.usersRepository.UpgradeToRed(ctx, userID)
 }
 
-func (s *UsersService) RefreshToken(ctx context.Context, token string) (string, *models.ResponseErr) {
-	refreshToken, respErr := s.refreshTokenRepo.GetToken(ctx, token)
-	if respErr != nil {
-		return "", respErr
-	}
-	if refreshToken.ExpiresAt.Before(time.Now()) {
-		return "", &models.ResponseErr{
-			Error:      "Token expired",
-			StatusCode: http.StatusUnauthorized,
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token. The old refresh token stops working, and using it again
+// revokes every token issued since the login it came from.
+func (s *UsersService) RefreshToken(ctx context.Context, token string, client models.ClientInfo) (string, string, *models.ResponseErr) {
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", "", &models.ResponseErr{
+			Error:      err.Error(),
+			StatusCode: http.StatusInternalServerError,
 		}
 	}
-	if refreshToken.RevokedAt.Valid {
-		return "", &models.ResponseErr{
-			Error:      "Token revoked",
-			StatusCode: http.StatusUnauthorized,
+	refreshToken, respErr := s.refreshTokenRepo.RotateRefreshToken(ctx, token, newRefreshToken, time.Now().Add(RefreshTokenTTL), client)
+	if respErr != nil {
+		if respErr.Code == models.CodeTokenReused {
+			s.denyFamilyQuietly(ctx, token)
 		}
+		return "", "", respErr
+	}
+
+	user, respErr := s.usersRepository.GetByID(ctx, refreshToken.UserID.String())
+	if respErr != nil {
+		return "", "", respErr
+	}
+	if user.SuspendedAt != nil {
+		return "", "", errSuspended()
 	}
-	newJWT, err := auth.MakeJWT(refreshToken.UserID, s.appState.Secret, 3600)
+	newJWT, err := auth.MakeUserJWT(user, refreshToken.FamilyID, s.appState.Keys, AccessTokenTTL)
 	if err != nil {
-		return "", &models.ResponseErr{
+		return "", "", &models.ResponseErr{
 			Error:      err.Error(),
 			StatusCode: http.StatusInternalServerError,
 		}
 	}
 
-	return newJWT, nil
+	return newJWT, refreshToken.Token, nil
+}
+
+func errLoginFailed() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "incorrect email or password",
+		StatusCode: http.StatusUnauthorized,
+		Code:       models.CodeLoginFailed,
+	}
+}
+
+func errSuspended() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Account suspended",
+		StatusCode: http.StatusForbidden,
+	}
 }
 
+// RevokeToken logs out the session of a refresh token. Access tokens issued
+// to the session stop working as well.
 func (s *UsersService) RevokeToken(ctx context.Context, token string) *models.ResponseErr {
-	return s.refreshTokenRepo.RevokeToken(ctx, token)
+	refreshToken, respErr := s.refreshTokenRepo.GetToken(ctx, token)
+	if respErr != nil {
+		if respErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return respErr
+	}
+
+	if respErr := s.refreshTokenRepo.RevokeToken(ctx, token); respErr != nil {
+		return respErr
+	}
+	return s.revocation.DenySessions(ctx, refreshToken.FamilyID)
+}
+
+// denyFamilyQuietly cuts off the access tokens of a session whose refresh
+// token was replayed. The store already revoked its refresh tokens, and
+// failing here must not hide that error from the caller.
+func (s *UsersService) denyFamilyQuietly(ctx context.Context, token string) {
+	refreshToken, respErr := s.refreshTokenRepo.GetToken(ctx, token)
+	if respErr == nil {
+		respErr = s.revocation.DenySessions(ctx, refreshToken.FamilyID)
+	}
+	if respErr != nil {
+		log.Printf("Could not deny access tokens of a reused refresh token: %s", respErr.Error)
+	}
 }
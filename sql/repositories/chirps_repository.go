@@ -5,10 +5,64 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/internal/search"
 	"github.com/karaMuha/go-chirpy/models"
+	"github.com/lib/pq"
 )
 
+// chirpColumns lists the columns scanChirp expects, in order. Queries name
+// them explicitly because the table carries columns the model does not map,
+// like search_vector.
+const chirpColumns = "id, created_at, updated_at, body, user_id, reply_to_id, deleted_at, repost_of_id, hidden_at"
+
+// threadChirpColumns selects the same columns as chirpColumns but shows
+// hidden chirps as tombstones, so threads keep their shape around them.
+const threadChirpColumns = "id, created_at, updated_at, CASE WHEN hidden_at IS NULL THEN body ELSE '' END, user_id, reply_to_id, COALESCE(deleted_at, hidden_at), repost_of_id, hidden_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanChirp(row rowScanner, chirp *models.Chirp) error {
+	var replyToID uuid.NullUUID
+	var deletedAt sql.NullTime
+	var repostOfID uuid.NullUUID
+	var hiddenAt sql.NullTime
+	if err := row.Scan(
+		&chirp.ID,
+		&chirp.CreatedAt,
+		&chirp.UpdatedAt,
+		&chirp.Body,
+		&chirp.UserID,
+		&replyToID,
+		&deletedAt,
+		&repostOfID,
+		&hiddenAt,
+	); err != nil {
+		return err
+	}
+
+	if replyToID.Valid {
+		chirp.ReplyToID = &replyToID.UUID
+	}
+	if deletedAt.Valid {
+		chirp.DeletedAt = &deletedAt.Time
+	}
+	if repostOfID.Valid {
+		chirp.RepostOfID = &repostOfID.UUID
+	}
+	if hiddenAt.Valid {
+		chirp.HiddenAt = &hiddenAt.Time
+	}
+
+	return nil
+}
+
 type ChirpsRepository struct {
 	db *sql.DB
 }
@@ -19,98 +73,121 @@ func NewChirpsRepository(db *sql.DB) ChirpsRepository {
 	}
 }
 
-func (r *ChirpsRepository) CreateChirp(ctx context.Context, body, userID string) (*models.Chirp, *models.ResponseErr) {
+func (r *ChirpsRepository) CreateChirp(ctx context.Context, body, userID, replyToID, repostOfID string) (*models.Chirp, *models.ResponseErr) {
 	query := `
-		INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-		VALUES (gen_random_uuid (), now(), now(), $1, $2)
-		RETURNING *;
+		INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, repost_of_id)
+		VALUES (gen_random_uuid (), now(), now(), $1, $2, $3, $4)
+		RETURNING ` + chirpColumns + `;
 	`
-	row := r.db.QueryRowContext(ctx, query, body, userID)
+	row := r.db.QueryRowContext(ctx, query, body, userID, nullString(replyToID), nullString(repostOfID))
 
 	var chirp models.Chirp
-	if err := row.Scan(
-		&chirp.ID,
-		&chirp.CreatedAt,
-		&chirp.UpdatedAt,
-		&chirp.Body,
-		&chirp.UserID,
-	); err != nil {
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+	if err := scanChirp(row, &chirp); err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return nil, &models.ResponseErr{
+				Error:      "Already rechirped",
+				StatusCode: http.StatusConflict,
+			}
 		}
+		return nil, dbError(err)
 	}
 
 	return &chirp, nil
 }
 
-func (r *ChirpsRepository) GetAll(ctx context.Context, authorID, sorting string) (*[]models.Chirp, *models.ResponseErr) {
-	var rows *sql.Rows
-	var err error
+func (r *ChirpsRepository) GetAll(ctx context.Context, authorID, sorting string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	conditions := []string{"deleted_at IS NULL", "hidden_at IS NULL"}
+	var args []any
 
-	if authorID == "" {
-		query := fmt.Sprintf(`
-		SELECT *
-		FROM chirps
-		ORDER BY created_at %s
-	`, sorting)
-		rows, err = r.db.QueryContext(ctx, query)
-	} else {
-		query := fmt.Sprintf(`
-		SELECT *
-		FROM chirps
-		WHERE user_id = $1
-		ORDER BY created_at %s
-	`, sorting)
-		rows, err = r.db.QueryContext(ctx, authorID, query)
+	if authorID != "" {
+		args = append(args, authorID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
 	}
-
-	if err != nil {
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+	if after != nil {
+		comparison := ">"
+		if sorting == "DESC" {
+			comparison = "<"
 		}
+		args = append(args, after.CreatedAt, after.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
 	}
 
-	var chripList []models.Chirp
-	for rows.Next() {
-		var chirp models.Chirp
-		err := rows.Scan(
-			&chirp.ID,
-			&chirp.CreatedAt,
-			&chirp.UpdatedAt,
-			&chirp.Body,
-			&chirp.UserID,
-		)
-		if err != nil {
-			return nil, &models.ResponseErr{
-				Error:      err.Error(),
-				StatusCode: http.StatusInternalServerError,
-			}
-		}
-		chripList = append(chripList, chirp)
-	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM chirps
+		WHERE %s
+		ORDER BY created_at %s, id %s
+		LIMIT $%d
+	`, chirpColumns, strings.Join(conditions, " AND "), sorting, sorting, len(args))
 
-	err = rows.Err()
-	if err != nil {
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
-		}
+	return r.queryChirps(ctx, query, args...)
+}
+
+func (r *ChirpsRepository) GetTimeline(ctx context.Context, followerID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	filter := "user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1)"
+	return r.queryNewestFirst(ctx, filter, followerID, after, limit)
+}
+
+func (r *ChirpsRepository) GetByHashtag(ctx context.Context, tag string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	filter := `id IN (
+		SELECT chirp_hashtags.chirp_id
+		FROM chirp_hashtags
+		JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+		WHERE hashtags.tag = $1
+	)`
+	return r.queryNewestFirst(ctx, filter, tag, after, limit)
+}
+
+func (r *ChirpsRepository) GetMentioning(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	filter := "id IN (SELECT chirp_id FROM mentions WHERE user_id = $1)"
+	return r.queryNewestFirst(ctx, filter, userID, after, limit)
+}
+
+// queryNewestFirst lists live chirps matching filter, newest first. filter
+// is a condition that refers to filterArg as $1.
+func (r *ChirpsRepository) queryNewestFirst(ctx context.Context, filter string, filterArg any, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	args := []any{filterArg}
+	condition := ""
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		condition = "AND (created_at, id) < ($2, $3)"
 	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM chirps
+		WHERE %s
+		AND deleted_at IS NULL AND hidden_at IS NULL %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`, chirpColumns, filter, condition, len(args))
 
-	return &chripList, nil
+	return r.queryChirps(ctx, query, args...)
+}
+
+func (r *ChirpsRepository) Search(ctx context.Context, query *search.Query, offset, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	sqlQuery := `
+		SELECT ` + chirpColumns + `
+		FROM chirps, to_tsquery('english', $1) query
+		WHERE search_vector @@ query AND deleted_at IS NULL AND hidden_at IS NULL
+		ORDER BY ts_rank(search_vector, query) DESC, created_at DESC, id DESC
+		OFFSET $2
+		LIMIT $3
+	`
+
+	return r.queryChirps(ctx, sqlQuery, query.TSQuery(), offset, limit)
 }
 
 func (r *ChirpsRepository) GetChirpByID(ctx context.Context, chirpID string) (*models.Chirp, *models.ResponseErr) {
 	query := `
-		SELECT *
+		SELECT ` + chirpColumns + `
 		FROM chirps
-		WHERE id = $1
+		WHERE id = $1 AND hidden_at IS NULL
 	`
 	row := r.db.QueryRowContext(ctx, query, chirpID)
 	var chirp models.Chirp
-	err := row.Scan(&chirp.ID, &chirp.CreatedAt, &chirp.UpdatedAt, &chirp.Body, &chirp.UserID)
+	err := scanChirp(row, &chirp)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &models.ResponseErr{
@@ -118,34 +195,240 @@ func (r *ChirpsRepository) GetChirpByID(ctx context.Context, chirpID string) (*m
 				StatusCode: http.StatusNotFound,
 			}
 		}
+		return nil, dbError(err)
+	}
+
+	return &chirp, nil
+}
+
+func (r *ChirpsRepository) GetByIDs(ctx context.Context, chirpIDs []string) (*[]models.Chirp, *models.ResponseErr) {
+	query := `
+		SELECT ` + chirpColumns + `
+		FROM chirps
+		WHERE id = ANY($1) AND hidden_at IS NULL
+	`
+
+	return r.queryChirps(ctx, query, pq.Array(chirpIDs))
+}
+
+func (r *ChirpsRepository) GetReplies(ctx context.Context, parentID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	args := []any{parentID}
+	condition := ""
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		condition = "AND (created_at, id) > ($2, $3)"
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM chirps
+		WHERE reply_to_id = $1 %s
+		ORDER BY created_at ASC, id ASC
+		LIMIT $%d
+	`, threadChirpColumns, condition, len(args))
+
+	return r.queryChirps(ctx, query, args...)
+}
+
+func (r *ChirpsRepository) GetDescendants(ctx context.Context, rootIDs []string, maxDepth int) (*[]models.Chirp, *models.ResponseErr) {
+	query := `
+		WITH RECURSIVE descendants AS (
+			SELECT ` + chirpColumns + `, 1 AS depth
+			FROM chirps
+			WHERE reply_to_id = ANY($1)
+			UNION ALL
+			SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.reply_to_id, c.deleted_at, c.repost_of_id, c.hidden_at, d.depth + 1
+			FROM chirps c
+			JOIN descendants d ON c.reply_to_id = d.id
+			WHERE d.depth < $2
+		)
+		SELECT ` + threadChirpColumns + `
+		FROM descendants
+		ORDER BY created_at ASC, id ASC
+	`
+
+	return r.queryChirps(ctx, query, pq.Array(rootIDs), maxDepth)
+}
+
+func (r *ChirpsRepository) GetAncestors(ctx context.Context, chirpID string) (*[]models.Chirp, *models.ResponseErr) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT ` + chirpColumns + `, 0 AS depth
+			FROM chirps
+			WHERE id = (SELECT reply_to_id FROM chirps WHERE id = $1)
+			UNION ALL
+			SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.reply_to_id, c.deleted_at, c.repost_of_id, c.hidden_at, a.depth + 1
+			FROM chirps c
+			JOIN ancestors a ON c.id = a.reply_to_id
+		)
+		SELECT ` + threadChirpColumns + `
+		FROM ancestors
+		ORDER BY depth DESC
+	`
+
+	return r.queryChirps(ctx, query, chirpID)
+}
+
+func (r *ChirpsRepository) UpdateChirp(ctx context.Context, chirpID, body string) (*models.Chirp, *models.ResponseErr) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer tx.Rollback()
+
+	var previousBody string
+	var writtenAt time.Time
+	var deletedAt, hiddenAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT body, updated_at, deleted_at, hidden_at FROM chirps WHERE id = $1 FOR UPDATE`, chirpID).Scan(&previousBody, &writtenAt, &deletedAt, &hiddenAt)
+	if err == sql.ErrNoRows || deletedAt.Valid || hiddenAt.Valid {
 		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+			Error:      "Chirp not found",
+			StatusCode: http.StatusNotFound,
 		}
 	}
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	query := `
+		INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, now());
+	`
+	if _, err := tx.ExecContext(ctx, query, chirpID, previousBody, writtenAt); err != nil {
+		return nil, dbError(err)
+	}
+
+	query = `
+		UPDATE chirps
+		SET body = $2, updated_at = now()
+		WHERE id = $1
+		RETURNING ` + chirpColumns
+	var chirp models.Chirp
+	if err := scanChirp(tx.QueryRowContext(ctx, query, chirpID, body), &chirp); err != nil {
+		return nil, dbError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, dbError(err)
+	}
 
 	return &chirp, nil
 }
 
+func (r *ChirpsRepository) GetRevisions(ctx context.Context, chirpID string) (*[]models.ChirpRevision, *models.ResponseErr) {
+	query := `
+		SELECT id, chirp_id, body, created_at, replaced_at
+		FROM chirp_revisions
+		WHERE chirp_id = $1
+		ORDER BY replaced_at ASC, id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, chirpID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	revisions := []models.ChirpRevision{}
+	for rows.Next() {
+		var revision models.ChirpRevision
+		if err := rows.Scan(&revision.ID, &revision.ChirpID, &revision.Body, &revision.CreatedAt, &revision.ReplacedAt); err != nil {
+			return nil, dbError(err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &revisions, nil
+}
+
+// DeleteChirp removes a chirp together with its plain rechirps. A chirp that
+// still has replies is turned into a tombstone instead so its replies keep
+// their place in the thread. The parent row is locked first, which blocks
+// replies from being inserted between the check and the delete.
 func (r *ChirpsRepository) DeleteChirp(ctx context.Context, chirpID string) *models.ResponseErr {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbError(err)
+	}
+	defer tx.Rollback()
+
+	var deletedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT deleted_at FROM chirps WHERE id = $1 FOR UPDATE`, chirpID).Scan(&deletedAt)
+	if err == sql.ErrNoRows || deletedAt.Valid {
+		return &models.ResponseErr{
+			Error:      "Chirp not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	if err != nil {
+		return dbError(err)
+	}
+
+	var hasReplies bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM chirps WHERE reply_to_id = $1)`, chirpID).Scan(&hasReplies)
+	if err != nil {
+		return dbError(err)
+	}
+
 	query := `
 		DELETE FROM chirps
 		WHERE id = $1;
 	`
+	if hasReplies {
+		// Tombstones keep the row, so their revisions have to go by hand.
+		query = `
+			WITH dropped AS (
+				DELETE FROM chirp_revisions WHERE chirp_id = $1
+			)
+			UPDATE chirps
+			SET body = '', deleted_at = now(), updated_at = now()
+			WHERE id = $1;
+		`
+	}
+	if _, err := tx.ExecContext(ctx, query, chirpID); err != nil {
+		return dbError(err)
+	}
+
+	// Plain rechirps go with the original, unless they have replies. Those
+	// become tombstones like any other chirp with replies.
+	query = `
+		UPDATE chirps
+		SET deleted_at = now(), updated_at = now()
+		WHERE repost_of_id = $1 AND body = '' AND deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM chirps reply WHERE reply.reply_to_id = chirps.id);
+	`
+	if _, err := tx.ExecContext(ctx, query, chirpID); err != nil {
+		return dbError(err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM chirps WHERE repost_of_id = $1 AND body = '' AND deleted_at IS NULL`, chirpID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *ChirpsRepository) HideChirp(ctx context.Context, chirpID string) *models.ResponseErr {
+	query := `
+		UPDATE chirps
+		SET hidden_at = COALESCE(hidden_at, now())
+		WHERE id = $1
+	`
 	res, err := r.db.ExecContext(ctx, query, chirpID)
 	if err != nil {
-		return &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
-		}
+		return dbError(err)
 	}
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
-		}
+		return dbError(err)
 	}
 
 	if rowsAffected == 0 {
@@ -157,3 +440,33 @@ func (r *ChirpsRepository) DeleteChirp(ctx context.Context, chirpID string) *mod
 
 	return nil
 }
+
+func (r *ChirpsRepository) queryChirps(ctx context.Context, query string, args ...any) (*[]models.Chirp, *models.ResponseErr) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var chripList []models.Chirp
+	for rows.Next() {
+		var chirp models.Chirp
+		err := scanChirp(rows, &chirp)
+		if err != nil {
+			return nil, dbError(err)
+		}
+		chripList = append(chripList, chirp)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &chripList, nil
+}
+
+// nullString maps "" to SQL NULL for optional foreign keys.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
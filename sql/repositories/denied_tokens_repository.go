@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/lib/pq"
+)
+
+type DeniedTokensRepository struct {
+	db *sql.DB
+}
+
+func NewDeniedTokensRepository(db *sql.DB) DeniedTokensRepository {
+	return DeniedTokensRepository{
+		db: db,
+	}
+}
+
+func (r *DeniedTokensRepository) DenyTokens(ctx context.Context, ids []string, expiresAt time.Time) *models.ResponseErr {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// created_at is bumped on conflict too, so instances syncing by it pick
+	// up the new expiry.
+	query := `
+		INSERT INTO denied_tokens (id, created_at, expires_at)
+		SELECT id, $2, $3 FROM unnest($1::text[]) AS id
+		ON CONFLICT (id) DO UPDATE
+		SET created_at = EXCLUDED.created_at,
+			expires_at = GREATEST(denied_tokens.expires_at, EXCLUDED.expires_at)
+	`
+	_, err := r.db.ExecContext(ctx, query, pq.Array(ids), time.Now().UTC(), expiresAt.UTC())
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *DeniedTokensRepository) ListDeniedTokens(ctx context.Context, since time.Time) ([]models.DeniedToken, *models.ResponseErr) {
+	query := `
+		SELECT id, created_at, expires_at
+		FROM denied_tokens
+		WHERE created_at >= $1 AND expires_at > $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, since.UTC(), time.Now().UTC())
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	tokens := []models.DeniedToken{}
+	for rows.Next() {
+		var token models.DeniedToken
+		if err := rows.Scan(&token.ID, &token.CreatedAt, &token.ExpiresAt); err != nil {
+			return nil, dbError(err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return tokens, nil
+}
+
+func (r *DeniedTokensRepository) DeleteExpiredDeniedTokens(ctx context.Context) *models.ResponseErr {
+	query := `
+		DELETE FROM denied_tokens
+		WHERE expires_at <= $1
+	`
+	_, err := r.db.ExecContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/lib/pq"
+)
+
+// pqInvalidTextRepresentation is what Postgres raises when a value cannot
+// be parsed as its column's type, such as a malformed uuid.
+const pqInvalidTextRepresentation = "22P02"
+
+// dbError wraps a database error. Values Postgres could not parse are the
+// caller's mistake and get a 400, everything else is a server error.
+func dbError(err error) *models.ResponseErr {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqInvalidTextRepresentation {
+		return errInvalidID()
+	}
+	return &models.ResponseErr{
+		Error:      err.Error(),
+		StatusCode: http.StatusInternalServerError,
+	}
+}
+
+// errInvalidID is returned for ids that slipped past validation, the
+// handlers normally reject them with the name of the field.
+func errInvalidID() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Invalid id",
+		StatusCode: http.StatusBadRequest,
+		Code:       models.CodeValidationFailed,
+	}
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type FollowsRepository struct {
+	db *sql.DB
+}
+
+func NewFollowsRepository(db *sql.DB) FollowsRepository {
+	return FollowsRepository{
+		db: db,
+	}
+}
+
+func (r *FollowsRepository) Follow(ctx context.Context, followerID, followeeID string) *models.ResponseErr {
+	query := `
+		INSERT INTO follows (follower_id, followee_id, created_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT DO NOTHING;
+	`
+	_, err := r.db.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *FollowsRepository) Unfollow(ctx context.Context, followerID, followeeID string) *models.ResponseErr {
+	query := `
+		DELETE FROM follows
+		WHERE follower_id = $1 AND followee_id = $2;
+	`
+	res, err := r.db.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
+		return &models.ResponseErr{
+			Error:      "Not following this user",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return nil
+}
+
+func (r *FollowsRepository) GetFollowers(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Follow, *models.ResponseErr) {
+	return r.list(ctx, "followee_id", "follower_id", userID, after, limit)
+}
+
+func (r *FollowsRepository) GetFollowing(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Follow, *models.ResponseErr) {
+	return r.list(ctx, "follower_id", "followee_id", userID, after, limit)
+}
+
+// list returns the follows where keyColumn equals userID, newest first,
+// paging on (created_at, otherColumn).
+func (r *FollowsRepository) list(ctx context.Context, keyColumn, otherColumn, userID string, after *pagination.Cursor, limit int) (*[]models.Follow, *models.ResponseErr) {
+	args := []any{userID}
+	condition := ""
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		condition = fmt.Sprintf("AND (created_at, %s) < ($2, $3)", otherColumn)
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT follower_id, followee_id, created_at
+		FROM follows
+		WHERE %s = $1 %s
+		ORDER BY created_at DESC, %s DESC
+		LIMIT $%d
+	`, keyColumn, condition, otherColumn, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var follows []models.Follow
+	for rows.Next() {
+		var follow models.Follow
+		if err := rows.Scan(&follow.FollowerID, &follow.FolloweeID, &follow.CreatedAt); err != nil {
+			return nil, dbError(err)
+		}
+		follows = append(follows, follow)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &follows, nil
+}
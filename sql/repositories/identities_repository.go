@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type IdentitiesRepository struct {
+	db *sql.DB
+}
+
+func NewIdentitiesRepository(db *sql.DB) IdentitiesRepository {
+	return IdentitiesRepository{
+		db: db,
+	}
+}
+
+func (r *IdentitiesRepository) GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, *models.ResponseErr) {
+	query := `
+		SELECT provider, subject, user_id, email, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2
+	`
+	row := r.db.QueryRowContext(ctx, query, provider, subject)
+
+	var identity models.Identity
+	if err := row.Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errIdentityNotFound()
+		}
+		return nil, dbError(err)
+	}
+
+	return &identity, nil
+}
+
+func (r *IdentitiesRepository) LinkIdentity(ctx context.Context, provider, subject, userID, email string) (*models.Identity, *models.ResponseErr) {
+	query := `
+		INSERT INTO user_identities (provider, subject, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4, now())
+		RETURNING provider, subject, user_id, email, created_at
+	`
+	row := r.db.QueryRowContext(ctx, query, provider, subject, userID, email)
+
+	var identity models.Identity
+	if err := row.Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt); err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return nil, errIdentityLinked()
+		}
+		return nil, dbError(err)
+	}
+
+	return &identity, nil
+}
+
+func errIdentityNotFound() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Identity not found",
+		StatusCode: http.StatusNotFound,
+	}
+}
+
+func errIdentityLinked() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "This account is already linked",
+		StatusCode: http.StatusConflict,
+	}
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/lib/pq"
+)
+
+type LikesRepository struct {
+	db *sql.DB
+}
+
+func NewLikesRepository(db *sql.DB) LikesRepository {
+	return LikesRepository{
+		db: db,
+	}
+}
+
+func (r *LikesRepository) Like(ctx context.Context, userID, chirpID string) *models.ResponseErr {
+	query := `
+		INSERT INTO likes (user_id, chirp_id, created_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT DO NOTHING;
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, chirpID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *LikesRepository) Unlike(ctx context.Context, userID, chirpID string) *models.ResponseErr {
+	query := `
+		DELETE FROM likes
+		WHERE user_id = $1 AND chirp_id = $2;
+	`
+	res, err := r.db.ExecContext(ctx, query, userID, chirpID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
+		return &models.ResponseErr{
+			Error:      "Like not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return nil
+}
+
+func (r *LikesRepository) CountLikes(ctx context.Context, chirpIDs []string) (map[uuid.UUID]int, *models.ResponseErr) {
+	query := `
+		SELECT chirp_id, count(*)
+		FROM likes
+		WHERE chirp_id = ANY($1)
+		GROUP BY chirp_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(chirpIDs))
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var chirpID uuid.UUID
+		var count int
+		if err := rows.Scan(&chirpID, &count); err != nil {
+			return nil, dbError(err)
+		}
+		counts[chirpID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return counts, nil
+}
+
+func (r *LikesRepository) GetLikedChirpIDs(ctx context.Context, userID string, chirpIDs []string) (map[uuid.UUID]bool, *models.ResponseErr) {
+	query := `
+		SELECT chirp_id
+		FROM likes
+		WHERE user_id = $1 AND chirp_id = ANY($2)
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(chirpIDs))
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	liked := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var chirpID uuid.UUID
+		if err := rows.Scan(&chirpID); err != nil {
+			return nil, dbError(err)
+		}
+		liked[chirpID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return liked, nil
+}
+
+func (r *LikesRepository) GetLikesByUser(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Like, *models.ResponseErr) {
+	args := []any{userID}
+	condition := ""
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		condition = "AND (likes.created_at, likes.chirp_id) < ($2, $3)"
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT likes.user_id, likes.chirp_id, likes.created_at
+		FROM likes
+		JOIN chirps ON chirps.id = likes.chirp_id
+		WHERE likes.user_id = $1 AND chirps.deleted_at IS NULL AND chirps.hidden_at IS NULL %s
+		ORDER BY likes.created_at DESC, likes.chirp_id DESC
+		LIMIT $%d
+	`, condition, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var likes []models.Like
+	for rows.Next() {
+		var like models.Like
+		if err := rows.Scan(&like.UserID, &like.ChirpID, &like.CreatedAt); err != nil {
+			return nil, dbError(err)
+		}
+		likes = append(likes, like)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &likes, nil
+}
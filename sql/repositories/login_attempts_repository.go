@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/lib/pq"
+)
+
+type LoginAttemptsRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptsRepository(db *sql.DB) LoginAttemptsRepository {
+	return LoginAttemptsRepository{
+		db: db,
+	}
+}
+
+func (r *LoginAttemptsRepository) RecordLoginAttempt(ctx context.Context, attempt models.LoginAttempt) *models.ResponseErr {
+	query := `
+		INSERT INTO login_attempts (id, email, user_id, ip, user_agent, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.ExecContext(ctx, query, attempt.ID, attempt.Email, attempt.UserID, attempt.IP, attempt.UserAgent, attempt.Reason, attempt.CreatedAt.UTC())
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *LoginAttemptsRepository) ListLoginAttempts(ctx context.Context, filter models.LoginAttemptFilter, after *pagination.Cursor, limit int) ([]models.LoginAttempt, *models.ResponseErr) {
+	conditions := []string{"true"}
+	var args []any
+
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Email != "" {
+		args = append(args, filter.Email)
+		conditions = append(conditions, fmt.Sprintf("email = $%d", len(args)))
+	}
+	if filter.IP != "" {
+		args = append(args, filter.IP)
+		conditions = append(conditions, fmt.Sprintf("ip = $%d", len(args)))
+	}
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT id, email, user_id, ip, user_agent, reason, created_at
+		FROM login_attempts
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	attempts := []models.LoginAttempt{}
+	for rows.Next() {
+		var attempt models.LoginAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.Email, &attempt.UserID, &attempt.IP, &attempt.UserAgent, &attempt.Reason, &attempt.CreatedAt); err != nil {
+			return nil, dbError(err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return attempts, nil
+}
+
+func (r *LoginAttemptsRepository) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, *models.ResponseErr) {
+	query := `
+		SELECT key, failures, last_failure_at
+		FROM login_throttles
+		WHERE key = ANY($1)
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	throttles := []models.LoginThrottle{}
+	for rows.Next() {
+		var throttle models.LoginThrottle
+		if err := rows.Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt); err != nil {
+			return nil, dbError(err)
+		}
+		throttles = append(throttles, throttle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return throttles, nil
+}
+
+func (r *LoginAttemptsRepository) AddLoginFailure(ctx context.Context, key string, at time.Time, resetAfter time.Duration) (*models.LoginThrottle, *models.ResponseErr) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN login_throttles.last_failure_at < $3 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at
+	`
+	at = at.UTC()
+	var throttle models.LoginThrottle
+	err := r.db.QueryRowContext(ctx, query, key, at, at.Add(-resetAfter)).Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &throttle, nil
+}
+
+func (r *LoginAttemptsRepository) ClearLoginThrottle(ctx context.Context, key string) *models.ResponseErr {
+	query := `
+		DELETE FROM login_throttles
+		WHERE key = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, key)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
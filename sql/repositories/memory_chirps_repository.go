@@ -0,0 +1,487 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/internal/search"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryChirpsRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryChirpsRepository(db *MemoryDB) MemoryChirpsRepository {
+	return MemoryChirpsRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryChirpsRepository) CreateChirp(ctx context.Context, body, userID, replyToID, repostOfID string) (*models.Chirp, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	var parsedReplyToID *uuid.UUID
+	if replyToID != "" {
+		id, respErr := parseUUID(replyToID)
+		if respErr != nil {
+			return nil, respErr
+		}
+		parsedReplyToID = &id
+	}
+	var parsedRepostOfID *uuid.UUID
+	if repostOfID != "" {
+		id, respErr := parseUUID(repostOfID)
+		if respErr != nil {
+			return nil, respErr
+		}
+		parsedRepostOfID = &id
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[parsedUserID]; !ok {
+		return nil, errForeignKey("chirps")
+	}
+	if parsedReplyToID != nil {
+		if _, ok := r.db.chirps[*parsedReplyToID]; !ok {
+			return nil, errForeignKey("chirps")
+		}
+	}
+	if parsedRepostOfID != nil && body == "" {
+		for _, other := range r.db.chirps {
+			if other.UserID == parsedUserID && isPlainRechirpOf(other, *parsedRepostOfID) {
+				return nil, &models.ResponseErr{
+					Error:      "Already rechirped",
+					StatusCode: http.StatusConflict,
+				}
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	chirp := &models.Chirp{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Body:       body,
+		UserID:     parsedUserID,
+		ReplyToID:  parsedReplyToID,
+		RepostOfID: parsedRepostOfID,
+	}
+	r.db.chirps[chirp.ID] = chirp
+
+	created := *chirp
+	return &created, nil
+}
+
+func (r *MemoryChirpsRepository) GetAll(ctx context.Context, authorID, sorting string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	var parsedAuthorID uuid.UUID
+	if authorID != "" {
+		var respErr *models.ResponseErr
+		parsedAuthorID, respErr = parseUUID(authorID)
+		if respErr != nil {
+			return nil, respErr
+		}
+	}
+
+	desc := sorting == "DESC"
+
+	r.db.mu.RLock()
+	var chripList []models.Chirp
+	for _, chirp := range r.db.chirps {
+		if !isListed(chirp) {
+			continue
+		}
+		if authorID != "" && chirp.UserID != parsedAuthorID {
+			continue
+		}
+		if after != nil && !isAfterCursor(chirp, after, desc) {
+			continue
+		}
+		chripList = append(chripList, *chirp)
+	}
+	r.db.mu.RUnlock()
+
+	sortChirps(chripList, desc)
+	if len(chripList) > limit {
+		chripList = chripList[:limit]
+	}
+
+	return &chripList, nil
+}
+
+func (r *MemoryChirpsRepository) GetTimeline(ctx context.Context, followerID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	parsedFollowerID, respErr := parseUUID(followerID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	return r.listNewestFirst(after, limit, func(chirp *models.Chirp) bool {
+		_, ok := r.db.follows[followKey{followerID: parsedFollowerID, followeeID: chirp.UserID}]
+		return ok
+	}), nil
+}
+
+func (r *MemoryChirpsRepository) GetByHashtag(ctx context.Context, tag string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	return r.listNewestFirst(after, limit, func(chirp *models.Chirp) bool {
+		_, ok := r.db.chirpHashtags[chirpHashtagKey{chirpID: chirp.ID, tag: tag}]
+		return ok
+	}), nil
+}
+
+func (r *MemoryChirpsRepository) GetMentioning(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	return r.listNewestFirst(after, limit, func(chirp *models.Chirp) bool {
+		_, ok := r.db.mentions[mentionKey{chirpID: chirp.ID, userID: parsedUserID}]
+		return ok
+	}), nil
+}
+
+// listNewestFirst lists live chirps accepted by match, newest first. match
+// runs with the read lock held.
+func (r *MemoryChirpsRepository) listNewestFirst(after *pagination.Cursor, limit int, match func(*models.Chirp) bool) *[]models.Chirp {
+	r.db.mu.RLock()
+	var chripList []models.Chirp
+	for _, chirp := range r.db.chirps {
+		if !isListed(chirp) || !match(chirp) {
+			continue
+		}
+		if after != nil && !isAfterCursor(chirp, after, true) {
+			continue
+		}
+		chripList = append(chripList, *chirp)
+	}
+	r.db.mu.RUnlock()
+
+	sortChirps(chripList, true)
+	if len(chripList) > limit {
+		chripList = chripList[:limit]
+	}
+
+	return &chripList
+}
+
+func (r *MemoryChirpsRepository) Search(ctx context.Context, query *search.Query, offset, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	type rankedChirp struct {
+		chirp models.Chirp
+		rank  float64
+	}
+
+	r.db.mu.RLock()
+	var matches []rankedChirp
+	for _, chirp := range r.db.chirps {
+		if !isListed(chirp) {
+			continue
+		}
+		rank := query.Rank(chirp.Body)
+		if rank == 0 {
+			continue
+		}
+		matches = append(matches, rankedChirp{chirp: *chirp, rank: rank})
+	}
+	r.db.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank > matches[j].rank
+		}
+		return compareChirps(&matches[i].chirp, matches[j].chirp.CreatedAt, matches[j].chirp.ID) > 0
+	})
+
+	var chripList []models.Chirp
+	for i := offset; i < len(matches) && len(chripList) < limit; i++ {
+		chripList = append(chripList, matches[i].chirp)
+	}
+
+	return &chripList, nil
+}
+
+func (r *MemoryChirpsRepository) GetChirpByID(ctx context.Context, chirpID string) (*models.Chirp, *models.ResponseErr) {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	chirp, ok := r.db.chirps[parsedChirpID]
+	if !ok || chirp.HiddenAt != nil {
+		return nil, &models.ResponseErr{
+			Error:      "Not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	found := *chirp
+	return &found, nil
+}
+
+func (r *MemoryChirpsRepository) GetByIDs(ctx context.Context, chirpIDs []string) (*[]models.Chirp, *models.ResponseErr) {
+	wanted, respErr := parseUUIDSet(chirpIDs)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var chripList []models.Chirp
+	for id := range wanted {
+		if chirp, ok := r.db.chirps[id]; ok && chirp.HiddenAt == nil {
+			chripList = append(chripList, *chirp)
+		}
+	}
+
+	return &chripList, nil
+}
+
+func (r *MemoryChirpsRepository) GetReplies(ctx context.Context, parentID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr) {
+	parsedParentID, respErr := parseUUID(parentID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	var chripList []models.Chirp
+	for _, chirp := range r.db.chirps {
+		if chirp.ReplyToID == nil || *chirp.ReplyToID != parsedParentID {
+			continue
+		}
+		if after != nil && !isAfterCursor(chirp, after, false) {
+			continue
+		}
+		chripList = append(chripList, threadView(chirp))
+	}
+	r.db.mu.RUnlock()
+
+	sortChirps(chripList, false)
+	if len(chripList) > limit {
+		chripList = chripList[:limit]
+	}
+
+	return &chripList, nil
+}
+
+func (r *MemoryChirpsRepository) GetDescendants(ctx context.Context, rootIDs []string, maxDepth int) (*[]models.Chirp, *models.ResponseErr) {
+	level := make(map[uuid.UUID]bool, len(rootIDs))
+	for _, rootID := range rootIDs {
+		id, respErr := parseUUID(rootID)
+		if respErr != nil {
+			return nil, respErr
+		}
+		level[id] = true
+	}
+
+	r.db.mu.RLock()
+	var chripList []models.Chirp
+	for depth := 1; depth <= maxDepth && len(level) > 0; depth++ {
+		next := make(map[uuid.UUID]bool)
+		for _, chirp := range r.db.chirps {
+			if chirp.ReplyToID != nil && level[*chirp.ReplyToID] {
+				chripList = append(chripList, threadView(chirp))
+				next[chirp.ID] = true
+			}
+		}
+		level = next
+	}
+	r.db.mu.RUnlock()
+
+	sortChirps(chripList, false)
+
+	return &chripList, nil
+}
+
+func (r *MemoryChirpsRepository) GetAncestors(ctx context.Context, chirpID string) (*[]models.Chirp, *models.ResponseErr) {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var chripList []models.Chirp
+	chirp, ok := r.db.chirps[parsedChirpID]
+	for ok && chirp.ReplyToID != nil {
+		chirp, ok = r.db.chirps[*chirp.ReplyToID]
+		if ok {
+			chripList = append([]models.Chirp{threadView(chirp)}, chripList...)
+		}
+	}
+
+	return &chripList, nil
+}
+
+func (r *MemoryChirpsRepository) UpdateChirp(ctx context.Context, chirpID, body string) (*models.Chirp, *models.ResponseErr) {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	chirp, ok := r.db.chirps[parsedChirpID]
+	if !ok || chirp.DeletedAt != nil || chirp.HiddenAt != nil {
+		return nil, &models.ResponseErr{
+			Error:      "Chirp not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	now := time.Now().UTC()
+	r.db.revisions[parsedChirpID] = append(r.db.revisions[parsedChirpID], models.ChirpRevision{
+		ID:         uuid.New(),
+		ChirpID:    parsedChirpID,
+		Body:       chirp.Body,
+		CreatedAt:  chirp.UpdatedAt,
+		ReplacedAt: now,
+	})
+	chirp.Body = body
+	chirp.UpdatedAt = now
+
+	updated := *chirp
+	return &updated, nil
+}
+
+func (r *MemoryChirpsRepository) GetRevisions(ctx context.Context, chirpID string) (*[]models.ChirpRevision, *models.ResponseErr) {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	revisions := append([]models.ChirpRevision{}, r.db.revisions[parsedChirpID]...)
+	return &revisions, nil
+}
+
+func (r *MemoryChirpsRepository) DeleteChirp(ctx context.Context, chirpID string) *models.ResponseErr {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	chirp, ok := r.db.chirps[parsedChirpID]
+	if !ok || chirp.DeletedAt != nil {
+		return &models.ResponseErr{
+			Error:      "Chirp not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	r.deleteOrTombstoneLocked(chirp)
+
+	// Plain rechirps go with the original, unless they have replies.
+	for _, other := range r.db.chirps {
+		if isPlainRechirpOf(other, parsedChirpID) {
+			r.deleteOrTombstoneLocked(other)
+		}
+	}
+
+	return nil
+}
+
+// deleteOrTombstoneLocked deletes chirp, or blanks it into a tombstone when
+// it has replies so the thread keeps its shape. Caller must hold db.mu.
+func (r *MemoryChirpsRepository) deleteOrTombstoneLocked(chirp *models.Chirp) {
+	for _, other := range r.db.chirps {
+		if other.ReplyToID != nil && *other.ReplyToID == chirp.ID {
+			now := time.Now().UTC()
+			chirp.Body = ""
+			chirp.DeletedAt = &now
+			chirp.UpdatedAt = now
+			delete(r.db.revisions, chirp.ID)
+			return
+		}
+	}
+	r.db.deleteChirpLocked(chirp.ID)
+}
+
+func (r *MemoryChirpsRepository) HideChirp(ctx context.Context, chirpID string) *models.ResponseErr {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	chirp, ok := r.db.chirps[parsedChirpID]
+	if !ok {
+		return &models.ResponseErr{
+			Error:      "Chirp not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	if chirp.HiddenAt == nil {
+		now := time.Now().UTC()
+		chirp.HiddenAt = &now
+	}
+
+	return nil
+}
+
+// isListed reports whether chirp shows up in listings, which leave out
+// tombstones and hidden chirps.
+func isListed(chirp *models.Chirp) bool {
+	return chirp.DeletedAt == nil && chirp.HiddenAt == nil
+}
+
+// threadView returns chirp as threads show it, with hidden chirps turned
+// into tombstones like threadChirpColumns does.
+func threadView(chirp *models.Chirp) models.Chirp {
+	view := *chirp
+	if view.HiddenAt != nil {
+		view.Body = ""
+		if view.DeletedAt == nil {
+			view.DeletedAt = view.HiddenAt
+		}
+	}
+	return view
+}
+
+// isPlainRechirpOf matches the rows covered by chirps_unique_rechirp_idx.
+func isPlainRechirpOf(chirp *models.Chirp, originalID uuid.UUID) bool {
+	return chirp.Body == "" && chirp.DeletedAt == nil && chirp.RepostOfID != nil && *chirp.RepostOfID == originalID
+}
+
+// compareChirps is compareRows for a chirp.
+func compareChirps(a *models.Chirp, createdAt time.Time, id uuid.UUID) int {
+	return compareRows(a.CreatedAt, a.ID, createdAt, id)
+}
+
+func isAfterCursor(chirp *models.Chirp, after *pagination.Cursor, desc bool) bool {
+	cmp := compareChirps(chirp, after.CreatedAt, after.ID)
+	if desc {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+func sortChirps(chirps []models.Chirp, desc bool) {
+	sort.Slice(chirps, func(i, j int) bool {
+		cmp := compareChirps(&chirps[i], chirps[j].CreatedAt, chirps[j].ID)
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
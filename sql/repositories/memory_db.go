@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// MemoryDB is the shared in-process state behind the Memory*Repository types.
+// It plays the role *sql.DB plays for the Postgres repositories: one instance
+// is created at startup and handed to every repository so that foreign key
+// style cascades work across tables.
+type MemoryDB struct {
+	mu            sync.RWMutex
+	users         map[uuid.UUID]*models.User
+	chirps        map[uuid.UUID]*models.Chirp
+	refreshTokens map[string]*models.RefreshToken
+	follows       map[followKey]time.Time
+	likes         map[likeKey]time.Time
+	chirpHashtags map[chirpHashtagKey]time.Time
+	mentions      map[mentionKey]time.Time
+	revisions     map[uuid.UUID][]models.ChirpRevision
+	reports       map[uuid.UUID]*models.Report
+	decisions     map[uuid.UUID][]models.Decision
+	userTokens    map[string]*models.UserToken
+	totp          map[uuid.UUID]*models.TOTP
+	// recoveryCodes maps each code to whether it was used.
+	recoveryCodes  map[recoveryCodeKey]bool
+	identities     map[identityKey]*models.Identity
+	signingKeys    map[string]*models.SigningKey
+	deniedTokens   map[string]*models.DeniedToken
+	loginAttempts  map[uuid.UUID]*models.LoginAttempt
+	loginThrottles map[string]*models.LoginThrottle
+}
+
+type followKey struct {
+	followerID uuid.UUID
+	followeeID uuid.UUID
+}
+
+type likeKey struct {
+	userID  uuid.UUID
+	chirpID uuid.UUID
+}
+
+// chirpHashtagKey stands in for the hashtags and chirp_hashtags tables, the
+// tag itself is the key since there is no need for a separate id in memory.
+type chirpHashtagKey struct {
+	chirpID uuid.UUID
+	tag     string
+}
+
+type recoveryCodeKey struct {
+	userID   uuid.UUID
+	codeHash string
+}
+
+type identityKey struct {
+	provider string
+	subject  string
+}
+
+type mentionKey struct {
+	chirpID uuid.UUID
+	userID  uuid.UUID
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		users:          make(map[uuid.UUID]*models.User),
+		chirps:         make(map[uuid.UUID]*models.Chirp),
+		refreshTokens:  make(map[string]*models.RefreshToken),
+		follows:        make(map[followKey]time.Time),
+		likes:          make(map[likeKey]time.Time),
+		chirpHashtags:  make(map[chirpHashtagKey]time.Time),
+		mentions:       make(map[mentionKey]time.Time),
+		revisions:      make(map[uuid.UUID][]models.ChirpRevision),
+		reports:        make(map[uuid.UUID]*models.Report),
+		decisions:      make(map[uuid.UUID][]models.Decision),
+		userTokens:     make(map[string]*models.UserToken),
+		totp:           make(map[uuid.UUID]*models.TOTP),
+		recoveryCodes:  make(map[recoveryCodeKey]bool),
+		identities:     make(map[identityKey]*models.Identity),
+		signingKeys:    make(map[string]*models.SigningKey),
+		deniedTokens:   make(map[string]*models.DeniedToken),
+		loginAttempts:  make(map[uuid.UUID]*models.LoginAttempt),
+		loginThrottles: make(map[string]*models.LoginThrottle),
+	}
+}
+
+// deleteUserLocked removes a user and everything referencing it, mirroring
+// the ON DELETE CASCADE constraints in sql/schema. Caller must hold db.mu.
+func (db *MemoryDB) deleteUserLocked(userID uuid.UUID) {
+	delete(db.users, userID)
+	for id, chirp := range db.chirps {
+		if chirp.UserID == userID {
+			db.deleteChirpLocked(id)
+		}
+	}
+	for token, refreshToken := range db.refreshTokens {
+		if refreshToken.UserID == userID {
+			delete(db.refreshTokens, token)
+		}
+	}
+	for tokenHash, userToken := range db.userTokens {
+		if userToken.UserID == userID {
+			delete(db.userTokens, tokenHash)
+		}
+	}
+	delete(db.totp, userID)
+	db.deleteRecoveryCodesLocked(userID)
+	for key, identity := range db.identities {
+		if identity.UserID == userID {
+			delete(db.identities, key)
+		}
+	}
+	for key := range db.follows {
+		if key.followerID == userID || key.followeeID == userID {
+			delete(db.follows, key)
+		}
+	}
+	for key := range db.likes {
+		if key.userID == userID {
+			delete(db.likes, key)
+		}
+	}
+	for key := range db.mentions {
+		if key.userID == userID {
+			delete(db.mentions, key)
+		}
+	}
+	for _, report := range db.reports {
+		if report.ReporterID != nil && *report.ReporterID == userID {
+			report.ReporterID = nil
+		}
+		if report.ClaimedBy != nil && *report.ClaimedBy == userID {
+			report.ClaimedBy = nil
+		}
+	}
+	for _, decisions := range db.decisions {
+		for i := range decisions {
+			if decisions[i].ModeratorID != nil && *decisions[i].ModeratorID == userID {
+				decisions[i].ModeratorID = nil
+			}
+		}
+	}
+	for _, attempt := range db.loginAttempts {
+		if attempt.UserID != nil && *attempt.UserID == userID {
+			attempt.UserID = nil
+		}
+	}
+}
+
+// deleteChirpLocked removes a chirp row with everything referencing it and
+// clears reply_to_id on its replies, mirroring the constraints in
+// sql/schema. Caller must hold db.mu.
+func (db *MemoryDB) deleteChirpLocked(chirpID uuid.UUID) {
+	delete(db.chirps, chirpID)
+	delete(db.revisions, chirpID)
+	for key := range db.likes {
+		if key.chirpID == chirpID {
+			delete(db.likes, key)
+		}
+	}
+	for key := range db.chirpHashtags {
+		if key.chirpID == chirpID {
+			delete(db.chirpHashtags, key)
+		}
+	}
+	for key := range db.mentions {
+		if key.chirpID == chirpID {
+			delete(db.mentions, key)
+		}
+	}
+	for _, chirp := range db.chirps {
+		if chirp.ReplyToID != nil && *chirp.ReplyToID == chirpID {
+			chirp.ReplyToID = nil
+		}
+	}
+}
+
+// deleteRecoveryCodesLocked removes all recovery codes of a user. Caller must
+// hold db.mu.
+func (db *MemoryDB) deleteRecoveryCodesLocked(userID uuid.UUID) {
+	for key := range db.recoveryCodes {
+		if key.userID == userID {
+			delete(db.recoveryCodes, key)
+		}
+	}
+}
+
+// compareRows orders rows by (created_at, id) the same way Postgres compares
+// the row tuple, uuids being compared bytewise.
+func compareRows(aCreatedAt time.Time, aID uuid.UUID, bCreatedAt time.Time, bID uuid.UUID) int {
+	if cmp := aCreatedAt.Compare(bCreatedAt); cmp != 0 {
+		return cmp
+	}
+	return bytes.Compare(aID[:], bID[:])
+}
+
+// parseUUID mirrors dbError for a malformed id bound to a UUID column.
+func parseUUID(value string) (uuid.UUID, *models.ResponseErr) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.UUID{}, errInvalidID()
+	}
+	return id, nil
+}
+
+func parseUUIDSet(values []string) (map[uuid.UUID]bool, *models.ResponseErr) {
+	set := make(map[uuid.UUID]bool, len(values))
+	for _, value := range values {
+		id, respErr := parseUUID(value)
+		if respErr != nil {
+			return nil, respErr
+		}
+		set[id] = true
+	}
+	return set, nil
+}
+
+func errForeignKey(table string) *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      fmt.Sprintf("insert or update on table %q violates foreign key constraint", table),
+		StatusCode: http.StatusInternalServerError,
+	}
+}
+
+func errCheckViolation(table string) *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      fmt.Sprintf("new row for relation %q violates check constraint", table),
+		StatusCode: http.StatusInternalServerError,
+	}
+}
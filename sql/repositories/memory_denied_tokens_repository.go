@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryDeniedTokensRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryDeniedTokensRepository(db *MemoryDB) MemoryDeniedTokensRepository {
+	return MemoryDeniedTokensRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryDeniedTokensRepository) DenyTokens(ctx context.Context, ids []string, expiresAt time.Time) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	expiresAt = expiresAt.UTC()
+	for _, id := range ids {
+		token, ok := r.db.deniedTokens[id]
+		if !ok {
+			r.db.deniedTokens[id] = &models.DeniedToken{ID: id, CreatedAt: now, ExpiresAt: expiresAt}
+			continue
+		}
+		token.CreatedAt = now
+		if expiresAt.After(token.ExpiresAt) {
+			token.ExpiresAt = expiresAt
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryDeniedTokensRepository) ListDeniedTokens(ctx context.Context, since time.Time) ([]models.DeniedToken, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	now := time.Now()
+	tokens := []models.DeniedToken{}
+	for _, token := range r.db.deniedTokens {
+		if !token.CreatedAt.Before(since) && token.ExpiresAt.After(now) {
+			tokens = append(tokens, *token)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (r *MemoryDeniedTokensRepository) DeleteExpiredDeniedTokens(ctx context.Context) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.db.deniedTokens {
+		if !token.ExpiresAt.After(now) {
+			delete(r.db.deniedTokens, id)
+		}
+	}
+
+	return nil
+}
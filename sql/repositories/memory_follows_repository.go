@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryFollowsRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryFollowsRepository(db *MemoryDB) MemoryFollowsRepository {
+	return MemoryFollowsRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryFollowsRepository) Follow(ctx context.Context, followerID, followeeID string) *models.ResponseErr {
+	parsedFollowerID, respErr := parseUUID(followerID)
+	if respErr != nil {
+		return respErr
+	}
+	parsedFolloweeID, respErr := parseUUID(followeeID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[parsedFollowerID]; !ok {
+		return errForeignKey("follows")
+	}
+	if _, ok := r.db.users[parsedFolloweeID]; !ok {
+		return errForeignKey("follows")
+	}
+	if parsedFollowerID == parsedFolloweeID {
+		return errCheckViolation("follows")
+	}
+
+	key := followKey{followerID: parsedFollowerID, followeeID: parsedFolloweeID}
+	if _, ok := r.db.follows[key]; !ok {
+		r.db.follows[key] = time.Now().UTC()
+	}
+
+	return nil
+}
+
+func (r *MemoryFollowsRepository) Unfollow(ctx context.Context, followerID, followeeID string) *models.ResponseErr {
+	parsedFollowerID, respErr := parseUUID(followerID)
+	if respErr != nil {
+		return respErr
+	}
+	parsedFolloweeID, respErr := parseUUID(followeeID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	key := followKey{followerID: parsedFollowerID, followeeID: parsedFolloweeID}
+	if _, ok := r.db.follows[key]; !ok {
+		return &models.ResponseErr{
+			Error:      "Not following this user",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	delete(r.db.follows, key)
+
+	return nil
+}
+
+func (r *MemoryFollowsRepository) GetFollowers(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Follow, *models.ResponseErr) {
+	return r.list(userID, after, limit, func(follow models.Follow) (uuid.UUID, uuid.UUID) {
+		return follow.FolloweeID, follow.FollowerID
+	})
+}
+
+func (r *MemoryFollowsRepository) GetFollowing(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Follow, *models.ResponseErr) {
+	return r.list(userID, after, limit, func(follow models.Follow) (uuid.UUID, uuid.UUID) {
+		return follow.FollowerID, follow.FolloweeID
+	})
+}
+
+// list returns the follows whose key side equals userID, newest first,
+// paging on (created_at, other side). sides splits a follow into its key
+// and other side.
+func (r *MemoryFollowsRepository) list(userID string, after *pagination.Cursor, limit int, sides func(models.Follow) (uuid.UUID, uuid.UUID)) (*[]models.Follow, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	compare := func(follow models.Follow, createdAt time.Time, id uuid.UUID) int {
+		if cmp := follow.CreatedAt.Compare(createdAt); cmp != 0 {
+			return cmp
+		}
+		_, other := sides(follow)
+		return bytes.Compare(other[:], id[:])
+	}
+
+	r.db.mu.RLock()
+	var follows []models.Follow
+	for key, createdAt := range r.db.follows {
+		follow := models.Follow{FollowerID: key.followerID, FolloweeID: key.followeeID, CreatedAt: createdAt}
+		if owner, _ := sides(follow); owner != parsedUserID {
+			continue
+		}
+		if after != nil && compare(follow, after.CreatedAt, after.ID) >= 0 {
+			continue
+		}
+		follows = append(follows, follow)
+	}
+	r.db.mu.RUnlock()
+
+	sort.Slice(follows, func(i, j int) bool {
+		_, other := sides(follows[j])
+		return compare(follows[i], follows[j].CreatedAt, other) > 0
+	})
+	if len(follows) > limit {
+		follows = follows[:limit]
+	}
+
+	return &follows, nil
+}
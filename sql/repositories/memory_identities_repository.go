@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryIdentitiesRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryIdentitiesRepository(db *MemoryDB) MemoryIdentitiesRepository {
+	return MemoryIdentitiesRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryIdentitiesRepository) GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	identity, ok := r.db.identities[identityKey{provider: provider, subject: subject}]
+	if !ok {
+		return nil, errIdentityNotFound()
+	}
+
+	found := *identity
+	return &found, nil
+}
+
+func (r *MemoryIdentitiesRepository) LinkIdentity(ctx context.Context, provider, subject, userID, email string) (*models.Identity, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[parsedUserID]; !ok {
+		return nil, errForeignKey("user_identities")
+	}
+	key := identityKey{provider: provider, subject: subject}
+	if _, ok := r.db.identities[key]; ok {
+		return nil, errIdentityLinked()
+	}
+
+	identity := &models.Identity{
+		Provider:  provider,
+		Subject:   subject,
+		UserID:    parsedUserID,
+		Email:     email,
+		CreatedAt: time.Now().UTC(),
+	}
+	r.db.identities[key] = identity
+
+	linked := *identity
+	return &linked, nil
+}
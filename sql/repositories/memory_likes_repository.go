@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryLikesRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryLikesRepository(db *MemoryDB) MemoryLikesRepository {
+	return MemoryLikesRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryLikesRepository) Like(ctx context.Context, userID, chirpID string) *models.ResponseErr {
+	key, respErr := parseLikeKey(userID, chirpID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[key.userID]; !ok {
+		return errForeignKey("likes")
+	}
+	if _, ok := r.db.chirps[key.chirpID]; !ok {
+		return errForeignKey("likes")
+	}
+
+	if _, ok := r.db.likes[key]; !ok {
+		r.db.likes[key] = time.Now().UTC()
+	}
+
+	return nil
+}
+
+func (r *MemoryLikesRepository) Unlike(ctx context.Context, userID, chirpID string) *models.ResponseErr {
+	key, respErr := parseLikeKey(userID, chirpID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.likes[key]; !ok {
+		return &models.ResponseErr{
+			Error:      "Like not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	delete(r.db.likes, key)
+
+	return nil
+}
+
+func (r *MemoryLikesRepository) CountLikes(ctx context.Context, chirpIDs []string) (map[uuid.UUID]int, *models.ResponseErr) {
+	wanted, respErr := parseUUIDSet(chirpIDs)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	counts := make(map[uuid.UUID]int)
+	for key := range r.db.likes {
+		if wanted[key.chirpID] {
+			counts[key.chirpID]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (r *MemoryLikesRepository) GetLikedChirpIDs(ctx context.Context, userID string, chirpIDs []string) (map[uuid.UUID]bool, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	wanted, respErr := parseUUIDSet(chirpIDs)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	liked := make(map[uuid.UUID]bool)
+	for key := range r.db.likes {
+		if key.userID == parsedUserID && wanted[key.chirpID] {
+			liked[key.chirpID] = true
+		}
+	}
+
+	return liked, nil
+}
+
+func (r *MemoryLikesRepository) GetLikesByUser(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Like, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	compare := func(like models.Like, createdAt time.Time, chirpID uuid.UUID) int {
+		if cmp := like.CreatedAt.Compare(createdAt); cmp != 0 {
+			return cmp
+		}
+		return bytes.Compare(like.ChirpID[:], chirpID[:])
+	}
+
+	r.db.mu.RLock()
+	var likes []models.Like
+	for key, createdAt := range r.db.likes {
+		if key.userID != parsedUserID {
+			continue
+		}
+		if chirp, ok := r.db.chirps[key.chirpID]; !ok || !isListed(chirp) {
+			continue
+		}
+		like := models.Like{UserID: key.userID, ChirpID: key.chirpID, CreatedAt: createdAt}
+		if after != nil && compare(like, after.CreatedAt, after.ID) >= 0 {
+			continue
+		}
+		likes = append(likes, like)
+	}
+	r.db.mu.RUnlock()
+
+	sort.Slice(likes, func(i, j int) bool {
+		return compare(likes[i], likes[j].CreatedAt, likes[j].ChirpID) > 0
+	})
+	if len(likes) > limit {
+		likes = likes[:limit]
+	}
+
+	return &likes, nil
+}
+
+func parseLikeKey(userID, chirpID string) (likeKey, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return likeKey{}, respErr
+	}
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return likeKey{}, respErr
+	}
+
+	return likeKey{userID: parsedUserID, chirpID: parsedChirpID}, nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryLoginAttemptsRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryLoginAttemptsRepository(db *MemoryDB) MemoryLoginAttemptsRepository {
+	return MemoryLoginAttemptsRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryLoginAttemptsRepository) RecordLoginAttempt(ctx context.Context, attempt models.LoginAttempt) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if attempt.UserID != nil {
+		if _, ok := r.db.users[*attempt.UserID]; !ok {
+			return errForeignKey("login_attempts")
+		}
+		userID := *attempt.UserID
+		attempt.UserID = &userID
+	}
+	attempt.CreatedAt = attempt.CreatedAt.UTC()
+	r.db.loginAttempts[attempt.ID] = &attempt
+
+	return nil
+}
+
+func (r *MemoryLoginAttemptsRepository) ListLoginAttempts(ctx context.Context, filter models.LoginAttemptFilter, after *pagination.Cursor, limit int) ([]models.LoginAttempt, *models.ResponseErr) {
+	r.db.mu.RLock()
+	attempts := []models.LoginAttempt{}
+	for _, attempt := range r.db.loginAttempts {
+		if filter.UserID != "" && (attempt.UserID == nil || attempt.UserID.String() != filter.UserID) {
+			continue
+		}
+		if filter.Email != "" && attempt.Email != filter.Email {
+			continue
+		}
+		if filter.IP != "" && attempt.IP != filter.IP {
+			continue
+		}
+		if after != nil && compareRows(attempt.CreatedAt, attempt.ID, after.CreatedAt, after.ID) >= 0 {
+			continue
+		}
+		attempts = append(attempts, *attempt)
+	}
+	r.db.mu.RUnlock()
+
+	slices.SortFunc(attempts, func(a, b models.LoginAttempt) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	if len(attempts) > limit {
+		attempts = attempts[:limit]
+	}
+
+	return attempts, nil
+}
+
+func (r *MemoryLoginAttemptsRepository) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	throttles := []models.LoginThrottle{}
+	for _, key := range keys {
+		if throttle, ok := r.db.loginThrottles[key]; ok {
+			throttles = append(throttles, *throttle)
+		}
+	}
+
+	return throttles, nil
+}
+
+func (r *MemoryLoginAttemptsRepository) AddLoginFailure(ctx context.Context, key string, at time.Time, resetAfter time.Duration) (*models.LoginThrottle, *models.ResponseErr) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	at = at.UTC()
+	throttle, ok := r.db.loginThrottles[key]
+	if !ok {
+		throttle = &models.LoginThrottle{Key: key}
+		r.db.loginThrottles[key] = throttle
+	}
+	if throttle.LastFailureAt.Before(at.Add(-resetAfter)) {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = at
+
+	found := *throttle
+	return &found, nil
+}
+
+func (r *MemoryLoginAttemptsRepository) ClearLoginThrottle(ctx context.Context, key string) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.loginThrottles, key)
+
+	return nil
+}
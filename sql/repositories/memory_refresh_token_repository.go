@@ -0,0 +1,269 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryRefreshTokenRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryRefreshTokenRepository(db *MemoryDB) MemoryRefreshTokenRepository {
+	return MemoryRefreshTokenRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryRefreshTokenRepository) SaveRefreshToken(ctx context.Context, token, userID string, expirationDate time.Time, client models.ClientInfo) (*models.RefreshToken, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[parsedUserID]; !ok {
+		return nil, errForeignKey("refresh_tokens")
+	}
+	if _, ok := r.db.refreshTokens[token]; ok {
+		return nil, &models.ResponseErr{
+			Error:      "duplicate key value violates unique constraint \"refresh_tokens_pkey\"",
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	now := time.Now().UTC()
+	refreshToken := &models.RefreshToken{
+		Token:     token,
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    parsedUserID,
+		ExpiresAt: expirationDate,
+		FamilyID:  uuid.New(),
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	}
+	r.db.refreshTokens[token] = refreshToken
+
+	saved := *refreshToken
+	return &saved, nil
+}
+
+func (r *MemoryRefreshTokenRepository) GetToken(ctx context.Context, token string) (*models.RefreshToken, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	refreshToken, ok := r.db.refreshTokens[token]
+	if !ok {
+		return nil, errRefreshTokenNotFound()
+	}
+
+	found := *refreshToken
+	return &found, nil
+}
+
+func (r *MemoryRefreshTokenRepository) RotateRefreshToken(ctx context.Context, oldToken, newToken string, expirationDate time.Time, client models.ClientInfo) (*models.RefreshToken, *models.ResponseErr) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	current, ok := r.db.refreshTokens[oldToken]
+	if !ok {
+		return nil, errRefreshTokenNotFound()
+	}
+
+	now := time.Now().UTC()
+	if current.RotatedAt.Valid {
+		for _, refreshToken := range r.db.refreshTokens {
+			if refreshToken.FamilyID == current.FamilyID && !refreshToken.RevokedAt.Valid {
+				refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+				refreshToken.UpdatedAt = now
+			}
+		}
+		return nil, errRefreshTokenReused()
+	}
+	if respErr := checkRefreshTokenUsable(current, now); respErr != nil {
+		return nil, respErr
+	}
+	if _, ok := r.db.refreshTokens[newToken]; ok {
+		return nil, &models.ResponseErr{
+			Error:      "duplicate key value violates unique constraint \"refresh_tokens_pkey\"",
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	current.RotatedAt = sql.NullTime{Time: now, Valid: true}
+	current.UpdatedAt = now
+	rotated := &models.RefreshToken{
+		Token:       newToken,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		UserID:      current.UserID,
+		ExpiresAt:   expirationDate,
+		FamilyID:    current.FamilyID,
+		ParentToken: sql.NullString{String: oldToken, Valid: true},
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+	}
+	r.db.refreshTokens[newToken] = rotated
+
+	found := *rotated
+	return &found, nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeToken(ctx context.Context, token string) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	revoked, ok := r.db.refreshTokens[token]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, refreshToken := range r.db.refreshTokens {
+		if refreshToken.FamilyID == revoked.FamilyID && !refreshToken.RevokedAt.Valid {
+			refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			refreshToken.UpdatedAt = now
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string) ([]uuid.UUID, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	sessionIDs := []uuid.UUID{}
+	for _, refreshToken := range r.db.refreshTokens {
+		if refreshToken.UserID == parsedUserID && !refreshToken.RevokedAt.Valid {
+			refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			refreshToken.UpdatedAt = now
+			if !slices.Contains(sessionIDs, refreshToken.FamilyID) {
+				sessionIDs = append(sessionIDs, refreshToken.FamilyID)
+			}
+		}
+	}
+
+	return sessionIDs, nil
+}
+
+func (r *MemoryRefreshTokenRepository) ListSessions(ctx context.Context, userID string) ([]models.Session, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	now := time.Now().UTC()
+	started := make(map[uuid.UUID]time.Time)
+	for _, refreshToken := range r.db.refreshTokens {
+		if createdAt, ok := started[refreshToken.FamilyID]; !ok || refreshToken.CreatedAt.Before(createdAt) {
+			started[refreshToken.FamilyID] = refreshToken.CreatedAt
+		}
+	}
+
+	sessions := []models.Session{}
+	for _, refreshToken := range r.db.refreshTokens {
+		if refreshToken.UserID != parsedUserID || !isLiveRefreshToken(refreshToken, now) {
+			continue
+		}
+		sessions = append(sessions, models.Session{
+			ID:         refreshToken.FamilyID,
+			CreatedAt:  started[refreshToken.FamilyID],
+			LastUsedAt: refreshToken.CreatedAt,
+			ExpiresAt:  refreshToken.ExpiresAt,
+			UserAgent:  refreshToken.UserAgent,
+			IP:         refreshToken.IP,
+		})
+	}
+	slices.SortFunc(sessions, func(a, b models.Session) int {
+		return b.LastUsedAt.Compare(a.LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeSession(ctx context.Context, userID, sessionID string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+	parsedSessionID, respErr := parseUUID(sessionID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	live := false
+	for _, refreshToken := range r.db.refreshTokens {
+		if refreshToken.UserID == parsedUserID && refreshToken.FamilyID == parsedSessionID && isLiveRefreshToken(refreshToken, now) {
+			live = true
+		}
+	}
+	if !live {
+		return errSessionNotFound()
+	}
+
+	for _, refreshToken := range r.db.refreshTokens {
+		if refreshToken.FamilyID == parsedSessionID && !refreshToken.RevokedAt.Valid {
+			refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			refreshToken.UpdatedAt = now
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) ([]uuid.UUID, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	parsedKeepID, respErr := parseUUID(keepSessionID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	sessionIDs := []uuid.UUID{}
+	for _, refreshToken := range r.db.refreshTokens {
+		if refreshToken.UserID == parsedUserID && refreshToken.FamilyID != parsedKeepID && !refreshToken.RevokedAt.Valid {
+			refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			refreshToken.UpdatedAt = now
+			if !slices.Contains(sessionIDs, refreshToken.FamilyID) {
+				sessionIDs = append(sessionIDs, refreshToken.FamilyID)
+			}
+		}
+	}
+
+	return sessionIDs, nil
+}
+
+// isLiveRefreshToken reports whether token is the usable head of its
+// session.
+func isLiveRefreshToken(refreshToken *models.RefreshToken, now time.Time) bool {
+	return !refreshToken.RotatedAt.Valid && !refreshToken.RevokedAt.Valid && refreshToken.ExpiresAt.After(now)
+}
@@ -0,0 +1,240 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryReportsRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryReportsRepository(db *MemoryDB) MemoryReportsRepository {
+	return MemoryReportsRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryReportsRepository) CreateReport(ctx context.Context, reporterID, targetType, targetID, targetUserID, reason, details string) (*models.Report, *models.ResponseErr) {
+	parsedReporterID, respErr := parseUUID(reporterID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	parsedTargetID, respErr := parseUUID(targetID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	parsedTargetUserID, respErr := parseUUID(targetUserID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[parsedReporterID]; !ok {
+		return nil, errForeignKey("reports")
+	}
+	for _, report := range r.db.reports {
+		if report.Status != models.ReportStatusResolved && report.ReporterID != nil && *report.ReporterID == parsedReporterID &&
+			report.TargetType == targetType && report.TargetID == parsedTargetID {
+			return nil, &models.ResponseErr{
+				Error:      "Already reported",
+				StatusCode: http.StatusConflict,
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	report := &models.Report{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		ReporterID:   &parsedReporterID,
+		TargetType:   targetType,
+		TargetID:     parsedTargetID,
+		TargetUserID: parsedTargetUserID,
+		Reason:       reason,
+		Details:      details,
+		Status:       models.ReportStatusOpen,
+	}
+	r.db.reports[report.ID] = report
+
+	created := *report
+	return &created, nil
+}
+
+func (r *MemoryReportsRepository) GetReport(ctx context.Context, reportID string) (*models.Report, *models.ResponseErr) {
+	parsedReportID, respErr := parseUUID(reportID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	report, ok := r.db.reports[parsedReportID]
+	if !ok {
+		return nil, &models.ResponseErr{
+			Error:      "Report not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	found := *report
+	return &found, nil
+}
+
+func (r *MemoryReportsRepository) ListReports(ctx context.Context, status string, after *pagination.Cursor, limit int) (*[]models.Report, *models.ResponseErr) {
+	r.db.mu.RLock()
+	var reports []models.Report
+	for _, report := range r.db.reports {
+		if status != "" && report.Status != status {
+			continue
+		}
+		if after != nil && compareRows(report.CreatedAt, report.ID, after.CreatedAt, after.ID) <= 0 {
+			continue
+		}
+		reports = append(reports, *report)
+	}
+	r.db.mu.RUnlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		return compareRows(reports[i].CreatedAt, reports[i].ID, reports[j].CreatedAt, reports[j].ID) < 0
+	})
+	if len(reports) > limit {
+		reports = reports[:limit]
+	}
+
+	return &reports, nil
+}
+
+func (r *MemoryReportsRepository) ClaimReport(ctx context.Context, reportID, moderatorID string) (*models.Report, *models.ResponseErr) {
+	return r.decide(reportID, moderatorID, models.DecisionClaim, "", func(report *models.Report, moderator uuid.UUID, now time.Time) *models.ResponseErr {
+		report.Status = models.ReportStatusClaimed
+		report.ClaimedBy = &moderator
+		report.ClaimedAt = &now
+		return nil
+	})
+}
+
+func (r *MemoryReportsRepository) ResolveReport(ctx context.Context, reportID, moderatorID, resolution, note string) (*models.Report, *models.ResponseErr) {
+	return r.decide(reportID, moderatorID, resolution, note, func(report *models.Report, moderator uuid.UUID, now time.Time) *models.ResponseErr {
+		if respErr := r.applyResolutionLocked(report.TargetID, report.TargetUserID, resolution, now); respErr != nil {
+			return respErr
+		}
+		report.Status = models.ReportStatusResolved
+		report.Resolution = resolution
+		report.ResolvedAt = &now
+		if report.ClaimedBy == nil {
+			report.ClaimedBy = &moderator
+			report.ClaimedAt = &now
+		}
+		return nil
+	})
+}
+
+// applyResolutionLocked mirrors applyResolution. Caller must hold db.mu.
+func (r *MemoryReportsRepository) applyResolutionLocked(targetID, targetUserID uuid.UUID, resolution string, now time.Time) *models.ResponseErr {
+	switch resolution {
+	case models.ResolutionHideChirp:
+		chirp, ok := r.db.chirps[targetID]
+		if !ok {
+			return &models.ResponseErr{
+				Error:      "Chirp not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		if chirp.HiddenAt == nil {
+			chirp.HiddenAt = &now
+		}
+	case models.ResolutionSuspendUser:
+		user, ok := r.db.users[targetUserID]
+		if !ok {
+			return &models.ResponseErr{
+				Error:      "User not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		if user.SuspendedAt == nil {
+			user.SuspendedAt = &now
+		}
+	}
+	return nil
+}
+
+// decide mirrors ReportsRepository.decide: apply runs on a report that
+// moderatorID may act on and the decision is recorded with it.
+func (r *MemoryReportsRepository) decide(reportID, moderatorID, action, note string, apply func(*models.Report, uuid.UUID, time.Time) *models.ResponseErr) (*models.Report, *models.ResponseErr) {
+	parsedReportID, respErr := parseUUID(reportID)
+	if respErr != nil {
+		return nil, respErr
+	}
+	parsedModeratorID, respErr := parseUUID(moderatorID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	report, ok := r.db.reports[parsedReportID]
+	if !ok {
+		return nil, &models.ResponseErr{
+			Error:      "Report not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	if respErr := checkModeratorMayAct(report, moderatorID); respErr != nil {
+		return nil, respErr
+	}
+	if action == models.DecisionClaim && report.ClaimedBy != nil {
+		found := *report
+		return &found, nil
+	}
+	if _, ok := r.db.users[parsedModeratorID]; !ok {
+		return nil, errForeignKey("moderation_decisions")
+	}
+
+	now := time.Now().UTC()
+	if respErr := apply(report, parsedModeratorID, now); respErr != nil {
+		return nil, respErr
+	}
+	report.UpdatedAt = now
+	r.db.decisions[parsedReportID] = append(r.db.decisions[parsedReportID], models.Decision{
+		ID:          uuid.New(),
+		ReportID:    parsedReportID,
+		ModeratorID: &parsedModeratorID,
+		Action:      action,
+		Note:        note,
+		CreatedAt:   now,
+	})
+
+	updated := *report
+	return &updated, nil
+}
+
+func (r *MemoryReportsRepository) GetDecisions(ctx context.Context, reportID string) (*[]models.Decision, *models.ResponseErr) {
+	parsedReportID, respErr := parseUUID(reportID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	decisions := append([]models.Decision{}, r.db.decisions[parsedReportID]...)
+	for i := range decisions {
+		if decisions[i].ModeratorID != nil {
+			moderatorID := *decisions[i].ModeratorID
+			decisions[i].ModeratorID = &moderatorID
+		}
+	}
+	return &decisions, nil
+}
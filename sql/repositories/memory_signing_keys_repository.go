@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemorySigningKeysRepository struct {
+	db *MemoryDB
+}
+
+func NewMemorySigningKeysRepository(db *MemoryDB) MemorySigningKeysRepository {
+	return MemorySigningKeysRepository{
+		db: db,
+	}
+}
+
+func (r *MemorySigningKeysRepository) ListSigningKeys(ctx context.Context) ([]models.SigningKey, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	keys := []models.SigningKey{}
+	for _, key := range r.db.signingKeys {
+		keys = append(keys, copySigningKey(key))
+	}
+	slices.SortFunc(keys, func(a, b models.SigningKey) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (r *MemorySigningKeysRepository) CreateSigningKey(ctx context.Context, key models.SigningKey) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.signingKeys[key.ID]; ok {
+		return &models.ResponseErr{
+			Error:      "duplicate key value violates unique constraint \"signing_keys_pkey\"",
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	key = copySigningKey(&key)
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.RetiredAt = nil
+	r.db.signingKeys[key.ID] = &key
+
+	return nil
+}
+
+func (r *MemorySigningKeysRepository) RetireSigningKeys(ctx context.Context, createdBefore, retiredAt time.Time) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	retiredAt = retiredAt.UTC()
+	for _, key := range r.db.signingKeys {
+		if key.CreatedAt.Before(createdBefore) && key.RetiredAt == nil {
+			key.RetiredAt = &retiredAt
+		}
+	}
+
+	return nil
+}
+
+func (r *MemorySigningKeysRepository) DeleteSigningKeys(ctx context.Context, retiredBefore time.Time) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, key := range r.db.signingKeys {
+		if key.RetiredAt != nil && key.RetiredAt.Before(retiredBefore) {
+			delete(r.db.signingKeys, id)
+		}
+	}
+
+	return nil
+}
+
+func copySigningKey(key *models.SigningKey) models.SigningKey {
+	copied := *key
+	copied.Private = slices.Clone(key.Private)
+	if key.RetiredAt != nil {
+		retiredAt := *key.RetiredAt
+		copied.RetiredAt = &retiredAt
+	}
+	return copied
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryTagsRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryTagsRepository(db *MemoryDB) MemoryTagsRepository {
+	return MemoryTagsRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryTagsRepository) SaveHashtags(ctx context.Context, chirpID string, tags []string, createdAt time.Time) *models.ResponseErr {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.chirps[parsedChirpID]; !ok {
+		return errForeignKey("chirp_hashtags")
+	}
+	for _, tag := range tags {
+		r.db.chirpHashtags[chirpHashtagKey{chirpID: parsedChirpID, tag: tag}] = createdAt
+	}
+
+	return nil
+}
+
+func (r *MemoryTagsRepository) SaveMentions(ctx context.Context, chirpID string, userIDs []string, createdAt time.Time) *models.ResponseErr {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return respErr
+	}
+	parsedUserIDs, respErr := parseUUIDSet(userIDs)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.chirps[parsedChirpID]; !ok {
+		return errForeignKey("mentions")
+	}
+	for userID := range parsedUserIDs {
+		if _, ok := r.db.users[userID]; !ok {
+			return errForeignKey("mentions")
+		}
+	}
+	for userID := range parsedUserIDs {
+		r.db.mentions[mentionKey{chirpID: parsedChirpID, userID: userID}] = createdAt
+	}
+
+	return nil
+}
+
+func (r *MemoryTagsRepository) DeleteEntities(ctx context.Context, chirpID string) *models.ResponseErr {
+	parsedChirpID, respErr := parseUUID(chirpID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for key := range r.db.chirpHashtags {
+		if key.chirpID == parsedChirpID {
+			delete(r.db.chirpHashtags, key)
+		}
+	}
+	for key := range r.db.mentions {
+		if key.chirpID == parsedChirpID {
+			delete(r.db.mentions, key)
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryTagsRepository) GetTrendingHashtags(ctx context.Context, since time.Time, limit int) (*[]models.TrendingHashtag, *models.ResponseErr) {
+	r.db.mu.RLock()
+	counts := make(map[string]int)
+	for key, createdAt := range r.db.chirpHashtags {
+		if createdAt.Before(since) {
+			continue
+		}
+		if chirp, ok := r.db.chirps[key.chirpID]; !ok || !isListed(chirp) {
+			continue
+		}
+		counts[key.tag]++
+	}
+	r.db.mu.RUnlock()
+
+	var trending []models.TrendingHashtag
+	for tag, count := range counts {
+		trending = append(trending, models.TrendingHashtag{Tag: tag, Count: count})
+	}
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Count != trending[j].Count {
+			return trending[i].Count > trending[j].Count
+		}
+		return trending[i].Tag < trending[j].Tag
+	})
+	if len(trending) > limit {
+		trending = trending[:limit]
+	}
+
+	return &trending, nil
+}
@@ -0,0 +1,973 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+func TestMemoryUsersUniqueEmail(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUsersRepository(NewMemoryDB())
+
+	_, respErr := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+
+	_, respErr = users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	if respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict but got: %v", respErr)
+	}
+
+	other, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	_, respErr = users.UpdateAccount(ctx, other.ID.String(), "a@example.com", "hash", other.Profile)
+	if respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict on update but got: %v", respErr)
+	}
+}
+
+func TestMemoryUsersUniqueUsername(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUsersRepository(NewMemoryDB())
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "Alice"})
+	_, respErr := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "alice"})
+	if respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict but got: %v", respErr)
+	}
+
+	found, respErr := users.GetByUsername(ctx, "ALICE")
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if found.ID != user.ID {
+		t.Errorf("Expected user %v but got %v", user.ID, found.ID)
+	}
+}
+
+func TestMemoryUsersRoles(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUsersRepository(NewMemoryDB())
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	if !slices.Equal(user.Roles, []string{models.RoleUser}) {
+		t.Fatalf("Expected only the user role but got %v", user.Roles)
+	}
+
+	users.GrantRole(ctx, user.ID.String(), models.RoleModerator)
+	granted, respErr := users.GrantRole(ctx, user.ID.String(), models.RoleModerator)
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if !slices.Equal(granted.Roles, []string{models.RoleUser, models.RoleModerator}) {
+		t.Errorf("Expected moderator granted once but got %v", granted.Roles)
+	}
+
+	revoked, _ := users.RevokeRole(ctx, user.ID.String(), models.RoleModerator)
+	if !slices.Equal(revoked.Roles, []string{models.RoleUser}) {
+		t.Errorf("Expected moderator revoked but got %v", revoked.Roles)
+	}
+	if !slices.Equal(granted.Roles, []string{models.RoleUser, models.RoleModerator}) {
+		t.Errorf("Expected earlier result to be unaffected but got %v", granted.Roles)
+	}
+}
+
+func TestMemoryChirpsSortOrder(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	other, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	for _, body := range []string{"first", "second", "third"} {
+		if _, respErr := chirps.CreateChirp(ctx, body, user.ID.String(), "", ""); respErr != nil {
+			t.Fatalf("Expected no error but got error: %v", respErr.Error)
+		}
+	}
+	chirps.CreateChirp(ctx, "other", other.ID.String(), "", "")
+
+	asc, _ := chirps.GetAll(ctx, user.ID.String(), "ASC", nil, 10)
+	if len(*asc) != 3 || (*asc)[0].Body != "first" || (*asc)[2].Body != "third" {
+		t.Errorf("Unexpected ascending order: %v", *asc)
+	}
+
+	desc, _ := chirps.GetAll(ctx, "", "DESC", nil, 10)
+	if len(*desc) != 4 || (*desc)[0].Body != "other" || (*desc)[3].Body != "first" {
+		t.Errorf("Unexpected descending order: %v", *desc)
+	}
+}
+
+func TestMemoryChirpsKeysetPagination(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	for i := 0; i < 5; i++ {
+		chirps.CreateChirp(ctx, "chirp", user.ID.String(), "", "")
+	}
+
+	for _, sorting := range []string{"ASC", "DESC"} {
+		all, _ := chirps.GetAll(ctx, "", sorting, nil, 10)
+
+		var paged []models.Chirp
+		var after *pagination.Cursor
+		for {
+			page, respErr := chirps.GetAll(ctx, "", sorting, after, 2)
+			if respErr != nil {
+				t.Fatalf("Expected no error but got error: %v", respErr.Error)
+			}
+			if len(*page) == 0 {
+				break
+			}
+			paged = append(paged, *page...)
+			last := (*page)[len(*page)-1]
+			after = &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+
+		if len(paged) != len(*all) {
+			t.Fatalf("%s: expected %d chirps across pages but got %d", sorting, len(*all), len(paged))
+		}
+		for i := range paged {
+			if paged[i].ID != (*all)[i].ID {
+				t.Errorf("%s: page order differs from full listing at index %d", sorting, i)
+			}
+		}
+	}
+}
+
+func TestMemoryResetCascades(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+	tokens := NewMemoryRefreshTokenRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	chirp, _ := chirps.CreateChirp(ctx, "hello", user.ID.String(), "", "")
+	tokens.SaveRefreshToken(ctx, "token", user.ID.String(), time.Now().Add(time.Hour), models.ClientInfo{})
+
+	if respErr := users.ResetTable(ctx); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+
+	if _, respErr := chirps.GetChirpByID(ctx, chirp.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected chirp to be deleted with its user but got: %v", respErr)
+	}
+	if _, respErr := tokens.GetToken(ctx, "token"); respErr == nil {
+		t.Error("Expected refresh token to be deleted with its user")
+	}
+}
+
+func TestMemoryChirpsUpdateKeepsRevisions(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	chirp, _ := chirps.CreateChirp(ctx, "first", user.ID.String(), "", "")
+
+	updated, respErr := chirps.UpdateChirp(ctx, chirp.ID.String(), "second")
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if updated.Body != "second" {
+		t.Errorf("Expected body %q but got %q", "second", updated.Body)
+	}
+
+	revisions, _ := chirps.GetRevisions(ctx, chirp.ID.String())
+	if len(*revisions) != 1 || (*revisions)[0].Body != "first" {
+		t.Fatalf("Expected one revision with the first body but got %v", *revisions)
+	}
+
+	chirps.DeleteChirp(ctx, chirp.ID.String())
+	revisions, _ = chirps.GetRevisions(ctx, chirp.ID.String())
+	if len(*revisions) != 0 {
+		t.Errorf("Expected revisions to be deleted with the chirp but got %v", *revisions)
+	}
+}
+
+func TestMemoryHiddenChirps(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	parent, _ := chirps.CreateChirp(ctx, "parent", user.ID.String(), "", "")
+	chirps.CreateChirp(ctx, "reply", user.ID.String(), parent.ID.String(), "")
+
+	if respErr := chirps.HideChirp(ctx, parent.ID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+
+	if _, respErr := chirps.GetChirpByID(ctx, parent.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected hidden chirp to be not found but got: %v", respErr)
+	}
+	listed, _ := chirps.GetAll(ctx, "", "ASC", nil, 10)
+	if len(*listed) != 1 || (*listed)[0].Body != "reply" {
+		t.Errorf("Expected only the reply to be listed but got %v", *listed)
+	}
+
+	replies, _ := chirps.GetReplies(ctx, parent.ID.String(), nil, 10)
+	ancestors, _ := chirps.GetAncestors(ctx, (*replies)[0].ID.String())
+	if len(*ancestors) != 1 || (*ancestors)[0].Body != "" || (*ancestors)[0].DeletedAt == nil {
+		t.Errorf("Expected hidden parent to show as a tombstone but got %v", *ancestors)
+	}
+}
+
+func TestMemoryRechirps(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	author, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	fan, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	original, _ := chirps.CreateChirp(ctx, "original", author.ID.String(), "", "")
+
+	plain, respErr := chirps.CreateChirp(ctx, "", fan.ID.String(), "", original.ID.String())
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if plain.RepostOfID == nil || *plain.RepostOfID != original.ID || plain.Body != "" {
+		t.Errorf("Expected a plain rechirp of the original but got %+v", plain)
+	}
+	if _, respErr := chirps.CreateChirp(ctx, "", fan.ID.String(), "", original.ID.String()); respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict for a second plain rechirp but got: %v", respErr)
+	}
+
+	for _, commentary := range []string{"so true", "still true"} {
+		quote, respErr := chirps.CreateChirp(ctx, commentary, fan.ID.String(), "", original.ID.String())
+		if respErr != nil {
+			t.Fatalf("Expected quotes next to a plain rechirp to be allowed but got error: %v", respErr.Error)
+		}
+		if quote.RepostOfID == nil || *quote.RepostOfID != original.ID || quote.Body != commentary {
+			t.Errorf("Expected a quote of the original but got %+v", quote)
+		}
+	}
+
+	chirps.DeleteChirp(ctx, plain.ID.String())
+	if _, respErr := chirps.CreateChirp(ctx, "", fan.ID.String(), "", original.ID.String()); respErr != nil {
+		t.Errorf("Expected rechirping again after deleting the rechirp to work but got error: %v", respErr.Error)
+	}
+}
+
+func TestMemoryDeleteChirpWithRepliesLeavesTombstone(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	parent, _ := chirps.CreateChirp(ctx, "parent", user.ID.String(), "", "")
+	reply, _ := chirps.CreateChirp(ctx, "reply", user.ID.String(), parent.ID.String(), "")
+
+	if respErr := chirps.DeleteChirp(ctx, parent.ID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	tombstone, respErr := chirps.GetChirpByID(ctx, parent.ID.String())
+	if respErr != nil || tombstone.DeletedAt == nil || tombstone.Body != "" {
+		t.Fatalf("Expected chirp with a reply to become a tombstone but got %v, %v", tombstone, respErr)
+	}
+	listed, _ := chirps.GetAll(ctx, "", "ASC", nil, 10)
+	if len(*listed) != 1 || (*listed)[0].ID != reply.ID {
+		t.Errorf("Expected only the reply to be listed but got %v", *listed)
+	}
+	if respErr := chirps.DeleteChirp(ctx, parent.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected not found when deleting a tombstone but got: %v", respErr)
+	}
+
+	if respErr := chirps.DeleteChirp(ctx, reply.ID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if _, respErr := chirps.GetChirpByID(ctx, reply.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected chirp without replies to be deleted but got: %v", respErr)
+	}
+}
+
+func TestMemoryThreads(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	root, _ := chirps.CreateChirp(ctx, "root", user.ID.String(), "", "")
+	first, _ := chirps.CreateChirp(ctx, "first", user.ID.String(), root.ID.String(), "")
+	second, _ := chirps.CreateChirp(ctx, "second", user.ID.String(), root.ID.String(), "")
+	nested, _ := chirps.CreateChirp(ctx, "nested", user.ID.String(), first.ID.String(), "")
+	deepest, _ := chirps.CreateChirp(ctx, "deepest", user.ID.String(), nested.ID.String(), "")
+
+	ids := func(list *[]models.Chirp) []uuid.UUID {
+		var found []uuid.UUID
+		for _, chirp := range *list {
+			found = append(found, chirp.ID)
+		}
+		return found
+	}
+
+	replies, _ := chirps.GetReplies(ctx, root.ID.String(), nil, 1)
+	if !slices.Equal(ids(replies), []uuid.UUID{first.ID}) {
+		t.Errorf("Expected the oldest direct reply on the first page but got %v", ids(replies))
+	}
+	last := (*replies)[0]
+	replies, _ = chirps.GetReplies(ctx, root.ID.String(), &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}, 10)
+	if !slices.Equal(ids(replies), []uuid.UUID{second.ID}) {
+		t.Errorf("Expected the second direct reply on the next page but got %v", ids(replies))
+	}
+
+	descendants, _ := chirps.GetDescendants(ctx, []string{root.ID.String()}, 2)
+	if !slices.Equal(ids(descendants), []uuid.UUID{first.ID, second.ID, nested.ID}) {
+		t.Errorf("Expected replies down to depth 2, oldest first, but got %v", ids(descendants))
+	}
+
+	ancestors, _ := chirps.GetAncestors(ctx, deepest.ID.String())
+	if !slices.Equal(ids(ancestors), []uuid.UUID{root.ID, first.ID, nested.ID}) {
+		t.Errorf("Expected the parents of the deepest reply, root first, but got %v", ids(ancestors))
+	}
+	ancestors, _ = chirps.GetAncestors(ctx, root.ID.String())
+	if len(*ancestors) != 0 {
+		t.Errorf("Expected the root to have no ancestors but got %v", ids(ancestors))
+	}
+}
+
+func TestMemoryDeleteOriginalKeepsRepliedRechirp(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	other, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	original, _ := chirps.CreateChirp(ctx, "original", user.ID.String(), "", "")
+	replied, _ := chirps.CreateChirp(ctx, "", user.ID.String(), "", original.ID.String())
+	plain, _ := chirps.CreateChirp(ctx, "", other.ID.String(), "", original.ID.String())
+	reply, _ := chirps.CreateChirp(ctx, "reply", other.ID.String(), replied.ID.String(), "")
+
+	if respErr := chirps.DeleteChirp(ctx, original.ID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+
+	tombstone, respErr := chirps.GetChirpByID(ctx, replied.ID.String())
+	if respErr != nil || tombstone.DeletedAt == nil {
+		t.Errorf("Expected rechirp with a reply to become a tombstone but got %v, %v", tombstone, respErr)
+	}
+	if _, respErr := chirps.GetChirpByID(ctx, plain.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected rechirp without replies to be deleted but got: %v", respErr)
+	}
+	kept, _ := chirps.GetChirpByID(ctx, reply.ID.String())
+	if kept.ReplyToID == nil || *kept.ReplyToID != replied.ID {
+		t.Errorf("Expected reply to stay in its thread but got parent %v", kept.ReplyToID)
+	}
+}
+
+func TestMemoryFollows(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	follows := NewMemoryFollowsRepository(db)
+
+	a, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	b, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	c, _ := users.CreateUser(ctx, "c@example.com", "hash", models.Profile{Username: "c"})
+
+	for _, followee := range []*models.User{b, c, b} {
+		if respErr := follows.Follow(ctx, a.ID.String(), followee.ID.String()); respErr != nil {
+			t.Fatalf("Expected no error but got error: %v", respErr.Error)
+		}
+	}
+	following, _ := follows.GetFollowing(ctx, a.ID.String(), nil, 10)
+	if len(*following) != 2 {
+		t.Fatalf("Expected following twice to be recorded once but got %v", *following)
+	}
+	if (*following)[0].FolloweeID != c.ID || (*following)[1].FolloweeID != b.ID {
+		t.Errorf("Expected newest follow first but got %v", *following)
+	}
+	followers, _ := follows.GetFollowers(ctx, b.ID.String(), nil, 10)
+	if len(*followers) != 1 || (*followers)[0].FollowerID != a.ID {
+		t.Errorf("Expected a to follow b but got %v", *followers)
+	}
+
+	if respErr := follows.Follow(ctx, a.ID.String(), a.ID.String()); respErr == nil {
+		t.Error("Expected following yourself to be refused")
+	}
+
+	if respErr := follows.Unfollow(ctx, a.ID.String(), b.ID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if respErr := follows.Unfollow(ctx, a.ID.String(), b.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected not found when unfollowing twice but got: %v", respErr)
+	}
+	followers, _ = follows.GetFollowers(ctx, b.ID.String(), nil, 10)
+	if len(*followers) != 0 {
+		t.Errorf("Expected no followers after unfollowing but got %v", *followers)
+	}
+}
+
+func TestMemoryTimelineKeysetPagination(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+	follows := NewMemoryFollowsRepository(db)
+
+	reader, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	followed, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	stranger, _ := users.CreateUser(ctx, "c@example.com", "hash", models.Profile{Username: "c"})
+	follows.Follow(ctx, reader.ID.String(), followed.ID.String())
+
+	var posted []uuid.UUID
+	for i := 0; i < 5; i++ {
+		chirp, _ := chirps.CreateChirp(ctx, "followed", followed.ID.String(), "", "")
+		posted = append(posted, chirp.ID)
+		chirps.CreateChirp(ctx, "stranger", stranger.ID.String(), "", "")
+	}
+
+	var paged []models.Chirp
+	var after *pagination.Cursor
+	for {
+		page, respErr := chirps.GetTimeline(ctx, reader.ID.String(), after, 2)
+		if respErr != nil {
+			t.Fatalf("Expected no error but got error: %v", respErr.Error)
+		}
+		if len(*page) == 0 {
+			break
+		}
+		paged = append(paged, *page...)
+		last := (*page)[len(*page)-1]
+		after = &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	if len(paged) != len(posted) {
+		t.Fatalf("Expected %d chirps of the followed user across pages but got %d", len(posted), len(paged))
+	}
+	for i, chirp := range paged {
+		if chirp.ID != posted[len(posted)-1-i] {
+			t.Errorf("Expected newest first, index %d is %v", i, chirp.ID)
+		}
+	}
+}
+
+func TestMemoryLikes(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+	likes := NewMemoryLikesRepository(db)
+
+	a, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	b, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	liked, _ := chirps.CreateChirp(ctx, "liked", a.ID.String(), "", "")
+	unliked, _ := chirps.CreateChirp(ctx, "unliked", a.ID.String(), "", "")
+	chirpIDs := []string{liked.ID.String(), unliked.ID.String()}
+
+	for _, user := range []*models.User{a, a, b} {
+		if respErr := likes.Like(ctx, user.ID.String(), liked.ID.String()); respErr != nil {
+			t.Fatalf("Expected no error but got error: %v", respErr.Error)
+		}
+	}
+	counts, _ := likes.CountLikes(ctx, chirpIDs)
+	if counts[liked.ID] != 2 {
+		t.Errorf("Expected liking twice to count once but got %d likes", counts[liked.ID])
+	}
+	if _, ok := counts[unliked.ID]; ok {
+		t.Errorf("Expected chirps without likes to be missing but got %v", counts)
+	}
+	likedByA, _ := likes.GetLikedChirpIDs(ctx, a.ID.String(), chirpIDs)
+	if !likedByA[liked.ID] || likedByA[unliked.ID] {
+		t.Errorf("Expected a to have liked only the first chirp but got %v", likedByA)
+	}
+
+	if respErr := likes.Unlike(ctx, a.ID.String(), liked.ID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if respErr := likes.Unlike(ctx, a.ID.String(), liked.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected not found when unliking twice but got: %v", respErr)
+	}
+	counts, _ = likes.CountLikes(ctx, chirpIDs)
+	if counts[liked.ID] != 1 {
+		t.Errorf("Expected only b's like to remain but got %d likes", counts[liked.ID])
+	}
+}
+
+func TestMemoryChirpsGetByIDs(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	first, _ := chirps.CreateChirp(ctx, "first", user.ID.String(), "", "")
+	second, _ := chirps.CreateChirp(ctx, "second", user.ID.String(), "", "")
+	hidden, _ := chirps.CreateChirp(ctx, "hidden", user.ID.String(), "", "")
+	chirps.HideChirp(ctx, hidden.ID.String())
+
+	found, respErr := chirps.GetByIDs(ctx, []string{second.ID.String(), first.ID.String(), hidden.ID.String(), uuid.NewString(), first.ID.String()})
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	var ids []uuid.UUID
+	for _, chirp := range *found {
+		ids = append(ids, chirp.ID)
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	expected := []uuid.UUID{first.ID, second.ID}
+	slices.SortFunc(expected, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	if !slices.Equal(ids, expected) {
+		t.Errorf("Expected each live chirp once, skipping hidden and unknown ids, but got %v", ids)
+	}
+
+	if _, respErr := chirps.GetByIDs(ctx, []string{"not-a-uuid"}); respErr == nil || respErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a bad request for a malformed id but got: %v", respErr)
+	}
+}
+
+func TestMemoryUserTokensSingleUse(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	tokens := NewMemoryUserTokensRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	tokens.CreateToken(ctx, "live", user.ID.String(), models.TokenPurposePasswordReset, time.Now().Add(time.Hour))
+	tokens.CreateToken(ctx, "expired", user.ID.String(), models.TokenPurposePasswordReset, time.Now().Add(-time.Minute))
+
+	if _, respErr := tokens.ConsumeToken(ctx, "live", "other_purpose"); respErr == nil {
+		t.Error("Expected token of another purpose to be rejected")
+	}
+	if _, respErr := tokens.LookupToken(ctx, "live", models.TokenPurposePasswordReset); respErr != nil {
+		t.Errorf("Expected lookup to find the live token but got error: %v", respErr.Error)
+	}
+	consumed, respErr := tokens.ConsumeToken(ctx, "live", models.TokenPurposePasswordReset)
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if consumed.UserID != user.ID {
+		t.Errorf("Expected user %v but got %v", user.ID, consumed.UserID)
+	}
+	if _, respErr := tokens.ConsumeToken(ctx, "live", models.TokenPurposePasswordReset); respErr == nil {
+		t.Error("Expected second use to be rejected")
+	}
+	if _, respErr := tokens.LookupToken(ctx, "live", models.TokenPurposePasswordReset); respErr == nil {
+		t.Error("Expected lookup of a used token to be rejected")
+	}
+	if _, respErr := tokens.ConsumeToken(ctx, "expired", models.TokenPurposePasswordReset); respErr == nil {
+		t.Error("Expected expired token to be rejected")
+	}
+}
+
+func TestMemoryUsersEmailChangeClearsVerification(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryUsersRepository(NewMemoryDB())
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	users.MarkEmailVerified(ctx, user.ID.String())
+
+	updated, _ := users.UpdateAccount(ctx, user.ID.String(), "a@example.com", "other", user.Profile)
+	if updated.EmailVerifiedAt == nil {
+		t.Error("Expected verification to survive an update that keeps the email")
+	}
+
+	updated, _ = users.UpdateAccount(ctx, user.ID.String(), "b@example.com", "other", user.Profile)
+	if updated.EmailVerifiedAt != nil {
+		t.Error("Expected verification to be cleared when the email changes")
+	}
+}
+
+func TestMemoryTwoFactorSingleUse(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	twoFactor := NewMemoryTwoFactorRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	userID := user.ID.String()
+	twoFactor.SaveTOTPSecret(ctx, userID, "secret")
+	if respErr := twoFactor.EnableTOTP(ctx, userID, 10, []string{"code"}); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+
+	if respErr := twoFactor.UseTOTPStep(ctx, userID, 10); respErr == nil {
+		t.Error("Expected step used during enrollment to be rejected")
+	}
+	if respErr := twoFactor.UseTOTPStep(ctx, userID, 11); respErr != nil {
+		t.Errorf("Expected no error but got error: %v", respErr.Error)
+	}
+	if respErr := twoFactor.UseTOTPStep(ctx, userID, 11); respErr == nil {
+		t.Error("Expected replayed step to be rejected")
+	}
+
+	if respErr := twoFactor.UseRecoveryCode(ctx, userID, "code"); respErr != nil {
+		t.Errorf("Expected no error but got error: %v", respErr.Error)
+	}
+	if respErr := twoFactor.UseRecoveryCode(ctx, userID, "code"); respErr == nil {
+		t.Error("Expected second use of recovery code to be rejected")
+	}
+}
+
+func TestMemoryIdentitiesLinkOnce(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	identities := NewMemoryIdentitiesRepository(db)
+
+	a, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	b, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+
+	if _, respErr := identities.LinkIdentity(ctx, "google", "sub-1", a.ID.String(), a.Email); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	_, respErr := identities.LinkIdentity(ctx, "google", "sub-1", b.ID.String(), b.Email)
+	if respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict when linking the same identity twice, got %v", respErr)
+	}
+
+	identity, respErr := identities.GetIdentity(ctx, "google", "sub-1")
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if identity.UserID != a.ID {
+		t.Errorf("Expected identity to stay linked to %v but got %v", a.ID, identity.UserID)
+	}
+	if _, respErr := identities.GetIdentity(ctx, "other", "sub-1"); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected not found for another provider, got %v", respErr)
+	}
+}
+
+func TestMemoryRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	refreshTokens := NewMemoryRefreshTokenRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	expiresAt := time.Now().Add(time.Hour)
+	refreshTokens.SaveRefreshToken(ctx, "first", user.ID.String(), expiresAt, models.ClientInfo{})
+	refreshTokens.SaveRefreshToken(ctx, "other-login", user.ID.String(), expiresAt, models.ClientInfo{})
+
+	second, respErr := refreshTokens.RotateRefreshToken(ctx, "first", "second", expiresAt, models.ClientInfo{})
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if second.ParentToken.String != "first" {
+		t.Errorf("Expected parent to be the rotated token but got %q", second.ParentToken.String)
+	}
+	if _, respErr := refreshTokens.RotateRefreshToken(ctx, "second", "third", expiresAt, models.ClientInfo{}); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+
+	if _, respErr := refreshTokens.RotateRefreshToken(ctx, "first", "fourth", expiresAt, models.ClientInfo{}); respErr == nil {
+		t.Fatal("Expected reuse of a rotated token to be rejected")
+	}
+	third, _ := refreshTokens.GetToken(ctx, "third")
+	if !third.RevokedAt.Valid {
+		t.Error("Expected reuse to revoke the latest token of the family")
+	}
+	if _, respErr := refreshTokens.RotateRefreshToken(ctx, "third", "fifth", expiresAt, models.ClientInfo{}); respErr == nil {
+		t.Error("Expected revoked token to be rejected")
+	}
+
+	other, _ := refreshTokens.GetToken(ctx, "other-login")
+	if other.RevokedAt.Valid || other.FamilyID == third.FamilyID {
+		t.Error("Expected tokens of other logins to be unaffected")
+	}
+}
+
+func TestMemoryRevokeRotatedTokenEndsSession(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	refreshTokens := NewMemoryRefreshTokenRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	expiresAt := time.Now().Add(time.Hour)
+	refreshTokens.SaveRefreshToken(ctx, "first", user.ID.String(), expiresAt, models.ClientInfo{})
+	refreshTokens.SaveRefreshToken(ctx, "other-login", user.ID.String(), expiresAt, models.ClientInfo{})
+	refreshTokens.RotateRefreshToken(ctx, "first", "second", expiresAt, models.ClientInfo{})
+
+	if respErr := refreshTokens.RevokeToken(ctx, "first"); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if _, respErr := refreshTokens.RotateRefreshToken(ctx, "second", "third", expiresAt, models.ClientInfo{}); respErr == nil {
+		t.Error("Expected revoking a rotated token to end its session")
+	}
+	other, _ := refreshTokens.GetToken(ctx, "other-login")
+	if other.RevokedAt.Valid {
+		t.Error("Expected tokens of other logins to be unaffected")
+	}
+}
+
+func TestMemorySessions(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	refreshTokens := NewMemoryRefreshTokenRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	userID := user.ID.String()
+	expiresAt := time.Now().Add(time.Hour)
+	laptop, _ := refreshTokens.SaveRefreshToken(ctx, "laptop", userID, expiresAt, models.ClientInfo{UserAgent: "laptop"})
+	phone, _ := refreshTokens.SaveRefreshToken(ctx, "phone", userID, expiresAt, models.ClientInfo{UserAgent: "phone"})
+	tablet, _ := refreshTokens.SaveRefreshToken(ctx, "tablet", userID, expiresAt, models.ClientInfo{UserAgent: "tablet"})
+	refreshTokens.RotateRefreshToken(ctx, "laptop", "laptop-2", expiresAt, models.ClientInfo{UserAgent: "laptop"})
+
+	sessions, _ := refreshTokens.ListSessions(ctx, userID)
+	if len(sessions) != 3 {
+		t.Fatalf("Expected one session per login but got %d", len(sessions))
+	}
+	if sessions[0].ID != laptop.FamilyID || sessions[0].CreatedAt != laptop.CreatedAt {
+		t.Errorf("Expected the just refreshed session first with its login time, got %+v", sessions[0])
+	}
+
+	if respErr := refreshTokens.RevokeSession(ctx, userID, phone.FamilyID.String()); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if respErr := refreshTokens.RevokeSession(ctx, userID, phone.FamilyID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected not found for a revoked session, got %v", respErr)
+	}
+
+	revoked, respErr := refreshTokens.RevokeOtherSessions(ctx, userID, laptop.FamilyID.String())
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if len(revoked) != 1 || revoked[0] != tablet.FamilyID {
+		t.Errorf("Expected only the tablet session to be reported revoked, got %v", revoked)
+	}
+	sessions, _ = refreshTokens.ListSessions(ctx, userID)
+	if len(sessions) != 1 || sessions[0].ID != laptop.FamilyID {
+		t.Errorf("Expected only the kept session to remain, got %+v", sessions)
+	}
+}
+
+func TestMemorySigningKeysRetireAndDelete(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	keys := NewMemorySigningKeysRepository(db)
+
+	now := time.Now().UTC()
+	keys.CreateSigningKey(ctx, models.SigningKey{ID: "old", Algorithm: "EdDSA", Private: []byte("a"), CreatedAt: now.Add(-2 * time.Hour)})
+	keys.CreateSigningKey(ctx, models.SigningKey{ID: "new", Algorithm: "EdDSA", Private: []byte("b"), CreatedAt: now})
+
+	if respErr := keys.RetireSigningKeys(ctx, now, now); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	list, _ := keys.ListSigningKeys(ctx)
+	if len(list) != 2 || list[0].ID != "new" || list[0].RetiredAt != nil || list[1].RetiredAt == nil {
+		t.Fatalf("Expected only the old key to be retired, got %+v", list)
+	}
+
+	keys.DeleteSigningKeys(ctx, now)
+	list, _ = keys.ListSigningKeys(ctx)
+	if len(list) != 2 {
+		t.Errorf("Expected a key retired just now to be kept, got %d keys", len(list))
+	}
+	keys.DeleteSigningKeys(ctx, now.Add(time.Second))
+	list, _ = keys.ListSigningKeys(ctx)
+	if len(list) != 1 || list[0].ID != "new" {
+		t.Errorf("Expected only the signing key to be left, got %+v", list)
+	}
+}
+
+func TestMemoryDeniedTokens(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	deniedTokens := NewMemoryDeniedTokensRepository(db)
+
+	before := time.Now()
+	deniedTokens.DenyTokens(ctx, []string{"live", "expired"}, time.Now().Add(time.Hour))
+	deniedTokens.DenyTokens(ctx, []string{"expired"}, time.Now().Add(-time.Second))
+	deniedTokens.DenyTokens(ctx, []string{"short"}, time.Now().Add(-time.Second))
+
+	tokens, _ := deniedTokens.ListDeniedTokens(ctx, before)
+	if len(tokens) != 2 {
+		t.Fatalf("Expected 2 unexpired entries but got %+v", tokens)
+	}
+	if tokens, _ := deniedTokens.ListDeniedTokens(ctx, time.Now().Add(time.Second)); len(tokens) != 0 {
+		t.Errorf("Expected no entries created after the last write but got %+v", tokens)
+	}
+
+	deniedTokens.DeleteExpiredDeniedTokens(ctx)
+	if len(db.deniedTokens) != 2 {
+		t.Errorf("Expected the expired entry to be deleted, %d entries left", len(db.deniedTokens))
+	}
+}
+
+func TestMemoryLoginThrottles(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	attempts := NewMemoryLoginAttemptsRepository(db)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		attempts.AddLoginFailure(ctx, "email:a@example.com", start, time.Hour)
+	}
+	throttle, _ := attempts.AddLoginFailure(ctx, "ip:192.0.2.1", start, time.Hour)
+	if throttle.Failures != 1 {
+		t.Errorf("Expected keys to be counted separately, got %d failures", throttle.Failures)
+	}
+
+	throttles, _ := attempts.GetLoginThrottles(ctx, []string{"email:a@example.com", "email:unknown@example.com"})
+	if len(throttles) != 1 || throttles[0].Failures != 3 {
+		t.Fatalf("Expected 3 failures for the account, got %+v", throttles)
+	}
+
+	throttle, _ = attempts.AddLoginFailure(ctx, "email:a@example.com", start.Add(2*time.Hour), time.Hour)
+	if throttle.Failures != 1 {
+		t.Errorf("Expected the count to start over after resetAfter, got %d failures", throttle.Failures)
+	}
+
+	attempts.ClearLoginThrottle(ctx, "email:a@example.com")
+	if throttles, _ := attempts.GetLoginThrottles(ctx, []string{"email:a@example.com"}); len(throttles) != 0 {
+		t.Errorf("Expected no counter after clearing, got %+v", throttles)
+	}
+}
+
+func TestMemoryLoginAttemptsNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	attempts := NewMemoryLoginAttemptsRepository(db)
+
+	user, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		attempts.RecordLoginAttempt(ctx, models.LoginAttempt{
+			ID:        uuid.New(),
+			Email:     user.Email,
+			UserID:    &user.ID,
+			IP:        "192.0.2.1",
+			Reason:    models.LoginFailureWrongPassword,
+			CreatedAt: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+	attempts.RecordLoginAttempt(ctx, models.LoginAttempt{ID: uuid.New(), Email: "b@example.com", IP: "192.0.2.1", Reason: models.LoginFailureUnknownEmail, CreatedAt: start})
+
+	page, _ := attempts.ListLoginAttempts(ctx, models.LoginAttemptFilter{UserID: user.ID.String()}, nil, 2)
+	if len(page) != 2 || !page[0].CreatedAt.After(page[1].CreatedAt) {
+		t.Fatalf("Expected the 2 newest attempts of the user, newest first, got %+v", page)
+	}
+	last := page[1]
+	rest, _ := attempts.ListLoginAttempts(ctx, models.LoginAttemptFilter{UserID: user.ID.String()}, &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}, 2)
+	if len(rest) != 1 || !rest[0].CreatedAt.Equal(start.UTC()) {
+		t.Errorf("Expected the oldest attempt on the next page, got %+v", rest)
+	}
+
+	users.ResetTable(ctx)
+	all, _ := attempts.ListLoginAttempts(ctx, models.LoginAttemptFilter{IP: "192.0.2.1"}, nil, 10)
+	if len(all) != 4 || all[0].UserID != nil {
+		t.Errorf("Expected attempts to outlive their user with user_id cleared, got %+v", all)
+	}
+}
+
+func TestMemoryReportsClaim(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	reports := NewMemoryReportsRepository(db)
+
+	reporter, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	target, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	first, _ := users.CreateUser(ctx, "c@example.com", "hash", models.Profile{Username: "c"})
+	second, _ := users.CreateUser(ctx, "d@example.com", "hash", models.Profile{Username: "d"})
+	report, _ := reports.CreateReport(ctx, reporter.ID.String(), models.ReportTargetUser, target.ID.String(), target.ID.String(), "spam", "")
+
+	claimed, respErr := reports.ClaimReport(ctx, report.ID.String(), first.ID.String())
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if claimed.Status != models.ReportStatusClaimed || claimed.ClaimedBy == nil || *claimed.ClaimedBy != first.ID {
+		t.Errorf("Expected report to be claimed by the first moderator but got %+v", claimed)
+	}
+
+	if _, respErr := reports.ClaimReport(ctx, report.ID.String(), first.ID.String()); respErr != nil {
+		t.Errorf("Expected claiming again to change nothing but got error: %v", respErr.Error)
+	}
+	if _, respErr := reports.ClaimReport(ctx, report.ID.String(), second.ID.String()); respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict for a report claimed by another moderator but got: %v", respErr)
+	}
+	if _, respErr := reports.ResolveReport(ctx, report.ID.String(), second.ID.String(), models.ResolutionSuspendUser, ""); respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict resolving a report claimed by another moderator but got: %v", respErr)
+	}
+	if user, _ := users.GetByID(ctx, target.ID.String()); user.SuspendedAt != nil {
+		t.Errorf("Expected a refused resolution to leave the user alone but got suspended at %v", user.SuspendedAt)
+	}
+
+	decisions, _ := reports.GetDecisions(ctx, report.ID.String())
+	if len(*decisions) != 1 || (*decisions)[0].Action != models.DecisionClaim {
+		t.Errorf("Expected only the first claim to be recorded but got %+v", *decisions)
+	}
+}
+
+func TestMemoryReportsResolveAppliesResolution(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+	reports := NewMemoryReportsRepository(db)
+
+	reporter, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	author, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	moderator, _ := users.CreateUser(ctx, "c@example.com", "hash", models.Profile{Username: "c"})
+	chirp, _ := chirps.CreateChirp(ctx, "spam", author.ID.String(), "", "")
+	chirpReport, _ := reports.CreateReport(ctx, reporter.ID.String(), models.ReportTargetChirp, chirp.ID.String(), author.ID.String(), "spam", "")
+	userReport, _ := reports.CreateReport(ctx, reporter.ID.String(), models.ReportTargetUser, author.ID.String(), author.ID.String(), "spam", "")
+
+	resolved, respErr := reports.ResolveReport(ctx, chirpReport.ID.String(), moderator.ID.String(), models.ResolutionHideChirp, "gone")
+	if respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if resolved.Status != models.ReportStatusResolved || resolved.ClaimedBy == nil || *resolved.ClaimedBy != moderator.ID {
+		t.Errorf("Expected report resolved and claimed by the moderator but got %+v", resolved)
+	}
+	if _, respErr := chirps.GetChirpByID(ctx, chirp.ID.String()); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected reported chirp to be hidden but got: %v", respErr)
+	}
+
+	if _, respErr := reports.ResolveReport(ctx, userReport.ID.String(), moderator.ID.String(), models.ResolutionSuspendUser, ""); respErr != nil {
+		t.Fatalf("Expected no error but got error: %v", respErr.Error)
+	}
+	if user, _ := users.GetByID(ctx, author.ID.String()); user.SuspendedAt == nil {
+		t.Error("Expected reported user to be suspended")
+	}
+
+	if _, respErr := reports.ResolveReport(ctx, userReport.ID.String(), moderator.ID.String(), models.ResolutionDismiss, ""); respErr == nil || respErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict for an already resolved report but got: %v", respErr)
+	}
+	decisions, _ := reports.GetDecisions(ctx, userReport.ID.String())
+	if len(*decisions) != 1 || (*decisions)[0].Action != models.ResolutionSuspendUser {
+		t.Errorf("Expected only the suspension to be recorded but got %+v", *decisions)
+	}
+}
+
+func TestMemoryReportsResolveMissingTarget(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	users := NewMemoryUsersRepository(db)
+	chirps := NewMemoryChirpsRepository(db)
+	reports := NewMemoryReportsRepository(db)
+
+	reporter, _ := users.CreateUser(ctx, "a@example.com", "hash", models.Profile{Username: "a"})
+	moderator, _ := users.CreateUser(ctx, "b@example.com", "hash", models.Profile{Username: "b"})
+	chirp, _ := chirps.CreateChirp(ctx, "spam", reporter.ID.String(), "", "")
+	report, _ := reports.CreateReport(ctx, reporter.ID.String(), models.ReportTargetChirp, chirp.ID.String(), reporter.ID.String(), "spam", "")
+	chirps.DeleteChirp(ctx, chirp.ID.String())
+
+	if _, respErr := reports.ResolveReport(ctx, report.ID.String(), moderator.ID.String(), models.ResolutionHideChirp, ""); respErr == nil || respErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected not found for a deleted chirp but got: %v", respErr)
+	}
+	found, _ := reports.GetReport(ctx, report.ID.String())
+	if found.Status != models.ReportStatusOpen {
+		t.Errorf("Expected report to stay open when its resolution fails but got %v", found.Status)
+	}
+}
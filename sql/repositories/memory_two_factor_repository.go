@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryTwoFactorRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryTwoFactorRepository(db *MemoryDB) MemoryTwoFactorRepository {
+	return MemoryTwoFactorRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryTwoFactorRepository) GetTOTP(ctx context.Context, userID string) (*models.TOTP, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	totp, ok := r.db.totp[parsedUserID]
+	if !ok {
+		return nil, errTOTPNotFound()
+	}
+
+	found := *totp
+	return &found, nil
+}
+
+func (r *MemoryTwoFactorRepository) SaveTOTPSecret(ctx context.Context, userID, secret string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[parsedUserID]; !ok {
+		return errForeignKey("user_totp")
+	}
+	if existing, ok := r.db.totp[parsedUserID]; ok && existing.EnabledAt != nil {
+		return errTOTPEnabled()
+	}
+
+	r.db.totp[parsedUserID] = &models.TOTP{
+		UserID:    parsedUserID,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	return nil
+}
+
+func (r *MemoryTwoFactorRepository) EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	totp, ok := r.db.totp[parsedUserID]
+	if !ok || totp.EnabledAt != nil {
+		return errTOTPEnabled()
+	}
+	now := time.Now().UTC()
+	totp.EnabledAt = &now
+	totp.LastStep = step
+
+	r.db.deleteRecoveryCodesLocked(parsedUserID)
+	for _, codeHash := range recoveryCodeHashes {
+		r.db.recoveryCodes[recoveryCodeKey{userID: parsedUserID, codeHash: codeHash}] = false
+	}
+
+	return nil
+}
+
+func (r *MemoryTwoFactorRepository) DisableTOTP(ctx context.Context, userID string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.totp, parsedUserID)
+	r.db.deleteRecoveryCodesLocked(parsedUserID)
+
+	return nil
+}
+
+func (r *MemoryTwoFactorRepository) UseTOTPStep(ctx context.Context, userID string, step int64) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	totp, ok := r.db.totp[parsedUserID]
+	if !ok || totp.EnabledAt == nil || totp.LastStep >= step {
+		return errInvalidSecondFactor()
+	}
+	totp.LastStep = step
+
+	return nil
+}
+
+func (r *MemoryTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	key := recoveryCodeKey{userID: parsedUserID, codeHash: codeHash}
+	used, ok := r.db.recoveryCodes[key]
+	if !ok || used {
+		return errInvalidSecondFactor()
+	}
+	r.db.recoveryCodes[key] = true
+
+	return nil
+}
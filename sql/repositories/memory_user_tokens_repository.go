@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryUserTokensRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryUserTokensRepository(db *MemoryDB) MemoryUserTokensRepository {
+	return MemoryUserTokensRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryUserTokensRepository) CreateToken(ctx context.Context, tokenHash, userID, purpose string, expiresAt time.Time) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[parsedUserID]; !ok {
+		return errForeignKey("user_tokens")
+	}
+	if _, ok := r.db.userTokens[tokenHash]; ok {
+		return &models.ResponseErr{
+			Error:      "duplicate key value violates unique constraint \"user_tokens_pkey\"",
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+
+	r.db.userTokens[tokenHash] = &models.UserToken{
+		TokenHash: tokenHash,
+		UserID:    parsedUserID,
+		Purpose:   purpose,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt.UTC(),
+	}
+
+	return nil
+}
+
+func (r *MemoryUserTokensRepository) ConsumeToken(ctx context.Context, tokenHash, purpose string) (*models.UserToken, *models.ResponseErr) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	token, ok := r.db.userTokens[tokenHash]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, errInvalidUserToken()
+	}
+	token.UsedAt = &now
+
+	consumed := *token
+	return &consumed, nil
+}
+
+func (r *MemoryUserTokensRepository) LookupToken(ctx context.Context, tokenHash, purpose string) (*models.UserToken, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	token, ok := r.db.userTokens[tokenHash]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || !token.ExpiresAt.After(time.Now().UTC()) {
+		return nil, errInvalidUserToken()
+	}
+
+	found := *token
+	return &found, nil
+}
+
+func (r *MemoryUserTokensRepository) DeleteTokens(ctx context.Context, userID, purpose string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for tokenHash, token := range r.db.userTokens {
+		if token.UserID == parsedUserID && token.Purpose == purpose {
+			delete(r.db.userTokens, tokenHash)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type MemoryUsersRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryUsersRepository(db *MemoryDB) MemoryUsersRepository {
+	return MemoryUsersRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryUsersRepository) CreateUser(ctx context.Context, email, password string, profile models.Profile) (*models.User, *models.ResponseErr) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if respErr := r.conflictLocked(email, profile.Username, uuid.UUID{}); respErr != nil {
+		return nil, respErr
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Email:     email,
+		Password:  password,
+		Profile:   profile,
+		Roles:     []string{models.RoleUser},
+	}
+	r.db.users[user.ID] = user
+
+	return copyUser(user), nil
+}
+
+func (r *MemoryUsersRepository) ResetTable(ctx context.Context) *models.ResponseErr {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id := range r.db.users {
+		r.db.deleteUserLocked(id)
+	}
+
+	return nil
+}
+
+func (r *MemoryUsersRepository) GetByID(ctx context.Context, userID string) (*models.User, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	user, ok := r.db.users[parsedUserID]
+	if !ok {
+		return nil, &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return copyUser(user), nil
+}
+
+func (r *MemoryUsersRepository) GetByEmail(ctx context.Context, email string) (*models.User, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, user := range r.db.users {
+		if user.Email == email {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, &models.ResponseErr{
+		Error:      "User not found",
+		StatusCode: http.StatusNotFound,
+	}
+}
+
+func (r *MemoryUsersRepository) GetByUsername(ctx context.Context, username string) (*models.User, *models.ResponseErr) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, user := range r.db.users {
+		if strings.EqualFold(user.Username, username) {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, &models.ResponseErr{
+		Error:      "User not found",
+		StatusCode: http.StatusNotFound,
+	}
+}
+
+func (r *MemoryUsersRepository) UpdateAccount(ctx context.Context, userID, email, password string, profile models.Profile) (*models.User, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[parsedUserID]
+	if !ok {
+		return nil, &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	if respErr := r.conflictLocked(email, profile.Username, parsedUserID); respErr != nil {
+		return nil, respErr
+	}
+
+	if user.Email != email {
+		user.EmailVerifiedAt = nil
+	}
+	user.Email = email
+	user.Password = password
+	user.Profile = profile
+	user.UpdatedAt = time.Now().UTC()
+
+	return copyUser(user), nil
+}
+
+func (r *MemoryUsersRepository) UpdatePassword(ctx context.Context, userID, password string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[parsedUserID]
+	if !ok {
+		return &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	user.Password = password
+	user.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+func (r *MemoryUsersRepository) MarkEmailVerified(ctx context.Context, userID string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[parsedUserID]
+	if !ok {
+		return &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now().UTC()
+		user.EmailVerifiedAt = &now
+	}
+
+	return nil
+}
+
+func (r *MemoryUsersRepository) UpgradeToRed(ctx context.Context, userID string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[parsedUserID]
+	if !ok {
+		return &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	user.IsChirpyRed = true
+
+	return nil
+}
+
+func (r *MemoryUsersRepository) SuspendUser(ctx context.Context, userID string) *models.ResponseErr {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[parsedUserID]
+	if !ok {
+		return &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	if user.SuspendedAt == nil {
+		now := time.Now().UTC()
+		user.SuspendedAt = &now
+	}
+
+	return nil
+}
+
+func (r *MemoryUsersRepository) GrantRole(ctx context.Context, userID, role string) (*models.User, *models.ResponseErr) {
+	return r.updateRoles(userID, func(roles []string) []string {
+		if slices.Contains(roles, role) {
+			return roles
+		}
+		return append(roles, role)
+	})
+}
+
+func (r *MemoryUsersRepository) RevokeRole(ctx context.Context, userID, role string) (*models.User, *models.ResponseErr) {
+	return r.updateRoles(userID, func(roles []string) []string {
+		return slices.DeleteFunc(roles, func(r string) bool { return r == role })
+	})
+}
+
+func (r *MemoryUsersRepository) updateRoles(userID string, update func(roles []string) []string) (*models.User, *models.ResponseErr) {
+	parsedUserID, respErr := parseUUID(userID)
+	if respErr != nil {
+		return nil, respErr
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[parsedUserID]
+	if !ok {
+		return nil, &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	user.Roles = update(slices.Clone(user.Roles))
+
+	return copyUser(user), nil
+}
+
+// copyUser returns a copy of user that shares no memory with the store.
+func copyUser(user *models.User) *models.User {
+	found := *user
+	found.Roles = slices.Clone(user.Roles)
+	return &found
+}
+
+// conflictLocked enforces the UNIQUE constraint on users.email and the
+// case-insensitive one on users.username, ignoring the user except. Caller
+// must hold db.mu.
+func (r *MemoryUsersRepository) conflictLocked(email, username string, except uuid.UUID) *models.ResponseErr {
+	for id, user := range r.db.users {
+		if id == except {
+			continue
+		}
+		if user.Email == email {
+			return &models.ResponseErr{
+				Error:      "Email already exists",
+				StatusCode: http.StatusConflict,
+			}
+		}
+		if strings.EqualFold(user.Username, username) {
+			return &models.ResponseErr{
+				Error:      "Username already exists",
+				StatusCode: http.StatusConflict,
+			}
+		}
+	}
+	return nil
+}
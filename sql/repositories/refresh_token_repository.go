@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"net/http"
+	"slices"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/karaMuha/go-chirpy/models"
 )
 
@@ -19,70 +21,278 @@ func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
 	}
 }
 
-func (r *RefreshTokenRepository) SaveRefreshToken(ctx context.Context, token, userID string, expirationDate time.Time) *models.ResponseErr {
+const refreshTokenColumns = `token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, parent_token, rotated_at, user_agent, ip`
+
+func scanRefreshToken(row rowScanner, refreshToken *models.RefreshToken) error {
+	return row.Scan(
+		&refreshToken.Token,
+		&refreshToken.CreatedAt,
+		&refreshToken.UpdatedAt,
+		&refreshToken.UserID,
+		&refreshToken.ExpiresAt,
+		&refreshToken.RevokedAt,
+		&refreshToken.FamilyID,
+		&refreshToken.ParentToken,
+		&refreshToken.RotatedAt,
+		&refreshToken.UserAgent,
+		&refreshToken.IP,
+	)
+}
+
+func (r *RefreshTokenRepository) SaveRefreshToken(ctx context.Context, token, userID string, expirationDate time.Time, client models.ClientInfo) (*models.RefreshToken, *models.ResponseErr) {
 	query := `
-		INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
-		VALUES ($1, now(), now(), $2, $3);
-	`
-	_, err := r.db.ExecContext(ctx, query, token, userID, expirationDate)
-	if err != nil {
-		return &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
-		}
+		INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, family_id, user_agent, ip)
+		VALUES ($1, now(), now(), $2, $3, gen_random_uuid(), $4, $5)
+		RETURNING ` + refreshTokenColumns
+	row := r.db.QueryRowContext(ctx, query, token, userID, expirationDate, client.UserAgent, client.IP)
+
+	var refreshToken models.RefreshToken
+	if err := scanRefreshToken(row, &refreshToken); err != nil {
+		return nil, dbError(err)
 	}
 
-	return nil
+	return &refreshToken, nil
 }
 
 func (r *RefreshTokenRepository) GetToken(ctx context.Context, token string) (*models.RefreshToken, *models.ResponseErr) {
 	query := `
-		SELECT *
+		SELECT ` + refreshTokenColumns + `
 		FROM refresh_tokens
 		WHERE token = $1
 	`
 	row := r.db.QueryRowContext(ctx, query, token)
 
 	var refreshToken models.RefreshToken
-	var revokedAt sql.NullTime
-	if err := row.Scan(
-		&refreshToken.Token,
-		&refreshToken.CreatedAt,
-		&refreshToken.UpdatedAt,
-		&refreshToken.UserID,
-		&refreshToken.ExpiresAt,
-		&revokedAt,
-	); err != nil {
+	if err := scanRefreshToken(row, &refreshToken); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errRefreshTokenNotFound()
+		}
+		return nil, dbError(err)
+	}
+
+	return &refreshToken, nil
+}
+
+func (r *RefreshTokenRepository) RotateRefreshToken(ctx context.Context, oldToken, newToken string, expirationDate time.Time, client models.ClientInfo) (*models.RefreshToken, *models.ResponseErr) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer tx.Rollback()
+
+	// Locking the row makes concurrent rotations of the same token queue up,
+	// so only the first one wins and the others count as reuse.
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+refreshTokenColumns+`
+		FROM refresh_tokens
+		WHERE token = $1
+		FOR UPDATE
+	`, oldToken)
+	var current models.RefreshToken
+	if err := scanRefreshToken(row, &current); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, &models.ResponseErr{
-				Error:      "Refresh token not found",
-				StatusCode: http.StatusUnauthorized,
-			}
+			return nil, errRefreshTokenNotFound()
+		}
+		return nil, dbError(err)
+	}
+
+	if current.RotatedAt.Valid {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE refresh_tokens
+			SET revoked_at = now(), updated_at = now()
+			WHERE family_id = $1 AND revoked_at IS NULL
+		`, current.FamilyID)
+		if err == nil {
+			err = tx.Commit()
 		}
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+		if err != nil {
+			return nil, dbError(err)
 		}
+		return nil, errRefreshTokenReused()
+	}
+	if respErr := checkRefreshTokenUsable(&current, time.Now()); respErr != nil {
+		return nil, respErr
 	}
 
-	refreshToken.RevokedAt = revokedAt
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET rotated_at = now(), updated_at = now()
+		WHERE token = $1
+	`, oldToken); err != nil {
+		return nil, dbError(err)
+	}
 
-	return &refreshToken, nil
+	row = tx.QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, family_id, parent_token, user_agent, ip)
+		VALUES ($1, now(), now(), $2, $3, $4, $5, $6, $7)
+		RETURNING `+refreshTokenColumns,
+		newToken, current.UserID, expirationDate, current.FamilyID, oldToken, client.UserAgent, client.IP)
+	var rotated models.RefreshToken
+	if err := scanRefreshToken(row, &rotated); err != nil {
+		return nil, dbError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &rotated, nil
 }
 
 func (r *RefreshTokenRepository) RevokeToken(ctx context.Context, token string) *models.ResponseErr {
 	query := `
 		UPDATE refresh_tokens
 		SET revoked_at = now(), updated_at = now()
-		WHERE token = $1;
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token = $1) AND revoked_at IS NULL;
 	`
 	_, err := r.db.ExecContext(ctx, query, token)
 	if err != nil {
-		return &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string) ([]uuid.UUID, *models.ResponseErr) {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = now(), updated_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+		RETURNING family_id;
+	`
+	return r.revokeSessions(ctx, query, userID)
+}
+
+func (r *RefreshTokenRepository) ListSessions(ctx context.Context, userID string) ([]models.Session, *models.ResponseErr) {
+	// Each live session has exactly one token that is neither rotated nor
+	// revoked, the session started when its family's first token was issued.
+	query := `
+		SELECT t.family_id,
+			(SELECT min(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id),
+			t.created_at, t.expires_at, t.user_agent, t.ip
+		FROM refresh_tokens t
+		WHERE t.user_id = $1 AND t.rotated_at IS NULL AND t.revoked_at IS NULL AND t.expires_at > now()
+		ORDER BY t.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.UserAgent, &session.IP); err != nil {
+			return nil, dbError(err)
 		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return sessions, nil
+}
+
+func (r *RefreshTokenRepository) RevokeSession(ctx context.Context, userID, sessionID string) *models.ResponseErr {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = now(), updated_at = now()
+		WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL
+			AND EXISTS (
+				SELECT 1 FROM refresh_tokens l
+				WHERE l.family_id = $2 AND l.rotated_at IS NULL AND l.revoked_at IS NULL AND l.expires_at > now()
+			)
+	`
+	res, err := r.db.ExecContext(ctx, query, userID, sessionID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
+		return errSessionNotFound()
 	}
 
 	return nil
 }
+
+func (r *RefreshTokenRepository) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) ([]uuid.UUID, *models.ResponseErr) {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = now(), updated_at = now()
+		WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL
+		RETURNING family_id;
+	`
+	return r.revokeSessions(ctx, query, userID, keepSessionID)
+}
+
+// revokeSessions runs an update returning the family of each token it
+// revoked, and returns those families once each.
+func (r *RefreshTokenRepository) revokeSessions(ctx context.Context, query string, args ...any) ([]uuid.UUID, *models.ResponseErr) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	sessionIDs := []uuid.UUID{}
+	for rows.Next() {
+		var sessionID uuid.UUID
+		if err := rows.Scan(&sessionID); err != nil {
+			return nil, dbError(err)
+		}
+		if !slices.Contains(sessionIDs, sessionID) {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return sessionIDs, nil
+}
+
+// checkRefreshTokenUsable refuses revoked and expired tokens.
+func checkRefreshTokenUsable(refreshToken *models.RefreshToken, now time.Time) *models.ResponseErr {
+	if refreshToken.RevokedAt.Valid {
+		return &models.ResponseErr{
+			Error:      "Token revoked",
+			StatusCode: http.StatusUnauthorized,
+		}
+	}
+	if !refreshToken.ExpiresAt.After(now) {
+		return &models.ResponseErr{
+			Error:      "Token expired",
+			StatusCode: http.StatusUnauthorized,
+		}
+	}
+	return nil
+}
+
+func errRefreshTokenNotFound() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Refresh token not found",
+		StatusCode: http.StatusUnauthorized,
+	}
+}
+
+func errRefreshTokenReused() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Refresh token was already used, please log in again",
+		StatusCode: http.StatusUnauthorized,
+		Code:       models.CodeTokenReused,
+	}
+}
+
+func errSessionNotFound() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Session not found",
+		StatusCode: http.StatusNotFound,
+	}
+}
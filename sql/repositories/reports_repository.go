@@ -0,0 +1,314 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+const reportColumns = "id, created_at, updated_at, reporter_id, target_type, target_id, target_user_id, reason, details, status, claimed_by, claimed_at, resolved_at, resolution"
+
+func scanReport(row rowScanner, report *models.Report) error {
+	var reporterID, claimedBy uuid.NullUUID
+	var claimedAt, resolvedAt sql.NullTime
+	if err := row.Scan(
+		&report.ID,
+		&report.CreatedAt,
+		&report.UpdatedAt,
+		&reporterID,
+		&report.TargetType,
+		&report.TargetID,
+		&report.TargetUserID,
+		&report.Reason,
+		&report.Details,
+		&report.Status,
+		&claimedBy,
+		&claimedAt,
+		&resolvedAt,
+		&report.Resolution,
+	); err != nil {
+		return err
+	}
+
+	if reporterID.Valid {
+		report.ReporterID = &reporterID.UUID
+	}
+	if claimedBy.Valid {
+		report.ClaimedBy = &claimedBy.UUID
+	}
+	if claimedAt.Valid {
+		report.ClaimedAt = &claimedAt.Time
+	}
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+
+	return nil
+}
+
+type ReportsRepository struct {
+	db *sql.DB
+}
+
+func NewReportsRepository(db *sql.DB) ReportsRepository {
+	return ReportsRepository{
+		db: db,
+	}
+}
+
+func (r *ReportsRepository) CreateReport(ctx context.Context, reporterID, targetType, targetID, targetUserID, reason, details string) (*models.Report, *models.ResponseErr) {
+	query := `
+		INSERT INTO reports (id, created_at, updated_at, reporter_id, target_type, target_id, target_user_id, reason, details)
+		VALUES (gen_random_uuid(), now(), now(), $1, $2, $3, $4, $5, $6)
+		RETURNING ` + reportColumns
+	row := r.db.QueryRowContext(ctx, query, reporterID, targetType, targetID, targetUserID, reason, details)
+
+	var report models.Report
+	if err := scanReport(row, &report); err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return nil, &models.ResponseErr{
+				Error:      "Already reported",
+				StatusCode: http.StatusConflict,
+			}
+		}
+		return nil, dbError(err)
+	}
+
+	return &report, nil
+}
+
+func (r *ReportsRepository) GetReport(ctx context.Context, reportID string) (*models.Report, *models.ResponseErr) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE id = $1
+	`
+	row := r.db.QueryRowContext(ctx, query, reportID)
+
+	var report models.Report
+	if err := scanReport(row, &report); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &models.ResponseErr{
+				Error:      "Report not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		return nil, dbError(err)
+	}
+
+	return &report, nil
+}
+
+func (r *ReportsRepository) ListReports(ctx context.Context, status string, after *pagination.Cursor, limit int) (*[]models.Report, *models.ResponseErr) {
+	conditions := []string{"true"}
+	var args []any
+
+	if status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM reports
+		WHERE %s
+		ORDER BY created_at ASC, id ASC
+		LIMIT $%d
+	`, reportColumns, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var reports []models.Report
+	for rows.Next() {
+		var report models.Report
+		if err := scanReport(rows, &report); err != nil {
+			return nil, dbError(err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &reports, nil
+}
+
+func (r *ReportsRepository) ClaimReport(ctx context.Context, reportID, moderatorID string) (*models.Report, *models.ResponseErr) {
+	query := `
+		UPDATE reports
+		SET status = 'claimed', claimed_by = $2, claimed_at = now(), updated_at = now()
+		WHERE id = $1
+		RETURNING ` + reportColumns
+	return r.decide(ctx, reportID, moderatorID, query, models.DecisionClaim, "", nil)
+}
+
+func (r *ReportsRepository) ResolveReport(ctx context.Context, reportID, moderatorID, resolution, note string) (*models.Report, *models.ResponseErr) {
+	query := `
+		UPDATE reports
+		SET status = 'resolved', resolution = $3, resolved_at = now(), updated_at = now(),
+			claimed_by = COALESCE(claimed_by, $2), claimed_at = COALESCE(claimed_at, now())
+		WHERE id = $1
+		RETURNING ` + reportColumns
+	return r.decide(ctx, reportID, moderatorID, query, resolution, note, applyResolution, resolution)
+}
+
+// applyResolution carries out the resolution of report in the transaction
+// that records it, so a decision and its effect are stored together.
+func applyResolution(ctx context.Context, tx *sql.Tx, report *models.Report) *models.ResponseErr {
+	var query, notFound string
+	var targetID uuid.UUID
+	switch report.Resolution {
+	case models.ResolutionHideChirp:
+		query = `UPDATE chirps SET hidden_at = COALESCE(hidden_at, now()) WHERE id = $1`
+		targetID, notFound = report.TargetID, "Chirp not found"
+	case models.ResolutionSuspendUser:
+		query = `UPDATE users SET suspended_at = COALESCE(suspended_at, now()) WHERE id = $1`
+		targetID, notFound = report.TargetUserID, "User not found"
+	default:
+		return nil
+	}
+
+	res, err := tx.ExecContext(ctx, query, targetID)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected == 0 {
+		return &models.ResponseErr{
+			Error:      notFound,
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return nil
+}
+
+// decide runs update on a report that moderatorID may act on, then effect
+// when it is set, and records the decision in the same transaction. update
+// gets the report id as $1, the moderator as $2 and extraArgs after that.
+// Claiming a report the moderator already holds changes nothing.
+func (r *ReportsRepository) decide(
+	ctx context.Context,
+	reportID, moderatorID, update, action, note string,
+	effect func(context.Context, *sql.Tx, *models.Report) *models.ResponseErr,
+	extraArgs ...any,
+) (*models.Report, *models.ResponseErr) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer tx.Rollback()
+
+	var report models.Report
+	row := tx.QueryRowContext(ctx, `SELECT `+reportColumns+` FROM reports WHERE id = $1 FOR UPDATE`, reportID)
+	if err := scanReport(row, &report); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &models.ResponseErr{
+				Error:      "Report not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		return nil, dbError(err)
+	}
+
+	if respErr := checkModeratorMayAct(&report, moderatorID); respErr != nil {
+		return nil, respErr
+	}
+	if action == models.DecisionClaim && report.ClaimedBy != nil {
+		return &report, nil
+	}
+
+	args := append([]any{reportID, moderatorID}, extraArgs...)
+	if err := scanReport(tx.QueryRowContext(ctx, update, args...), &report); err != nil {
+		return nil, dbError(err)
+	}
+	if effect != nil {
+		if respErr := effect(ctx, tx, &report); respErr != nil {
+			return nil, respErr
+		}
+	}
+
+	query := `
+		INSERT INTO moderation_decisions (id, report_id, moderator_id, action, note, created_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, now());
+	`
+	if _, err := tx.ExecContext(ctx, query, reportID, moderatorID, action, note); err != nil {
+		return nil, dbError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &report, nil
+}
+
+func (r *ReportsRepository) GetDecisions(ctx context.Context, reportID string) (*[]models.Decision, *models.ResponseErr) {
+	query := `
+		SELECT id, report_id, moderator_id, action, note, created_at
+		FROM moderation_decisions
+		WHERE report_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, reportID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var decisions []models.Decision
+	for rows.Next() {
+		var decision models.Decision
+		var moderatorID uuid.NullUUID
+		if err := rows.Scan(&decision.ID, &decision.ReportID, &moderatorID, &decision.Action, &decision.Note, &decision.CreatedAt); err != nil {
+			return nil, dbError(err)
+		}
+		if moderatorID.Valid {
+			decision.ModeratorID = &moderatorID.UUID
+		}
+		decisions = append(decisions, decision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &decisions, nil
+}
+
+// checkModeratorMayAct rejects decisions on resolved reports and on reports
+// another moderator has claimed.
+func checkModeratorMayAct(report *models.Report, moderatorID string) *models.ResponseErr {
+	if report.Status == models.ReportStatusResolved {
+		return &models.ResponseErr{
+			Error:      "Report already resolved",
+			StatusCode: http.StatusConflict,
+		}
+	}
+	if report.ClaimedBy != nil && report.ClaimedBy.String() != moderatorID {
+		return &models.ResponseErr{
+			Error:      "Report claimed by another moderator",
+			StatusCode: http.StatusConflict,
+		}
+	}
+	return nil
+}
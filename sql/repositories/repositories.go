@@ -0,0 +1,290 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karaMuha/go-chirpy/internal/pagination"
+	"github.com/karaMuha/go-chirpy/internal/search"
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+// ChirpsStore persists chirps. Implemented by ChirpsRepository (Postgres)
+// and MemoryChirpsRepository.
+type ChirpsStore interface {
+	// CreateChirp stores a new chirp. replyToID and repostOfID are empty
+	// unless the chirp is a reply or a rechirp. A user can only plainly
+	// rechirp (empty body) the same chirp once.
+	CreateChirp(ctx context.Context, body, userID, replyToID, repostOfID string) (*models.Chirp, *models.ResponseErr)
+	// GetAll lists chirps ordered by (created_at, id) in the given direction
+	// ("ASC" or "DESC"), starting strictly after the cursor when one is set.
+	// Tombstones are left out of GetAll, GetTimeline and Search.
+	GetAll(ctx context.Context, authorID, sorting string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr)
+	// GetTimeline lists chirps by the users followerID follows, newest first.
+	GetTimeline(ctx context.Context, followerID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr)
+	// GetByHashtag lists live chirps tagged with tag, newest first.
+	GetByHashtag(ctx context.Context, tag string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr)
+	// GetMentioning lists live chirps that mention userID, newest first.
+	GetMentioning(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr)
+	// Search returns chirps matching query, best match first.
+	Search(ctx context.Context, query *search.Query, offset, limit int) (*[]models.Chirp, *models.ResponseErr)
+	// GetChirpByID also returns tombstones.
+	GetChirpByID(ctx context.Context, chirpID string) (*models.Chirp, *models.ResponseErr)
+	// GetByIDs returns the chirps with the given ids in no particular order,
+	// skipping ids that do not exist.
+	GetByIDs(ctx context.Context, chirpIDs []string) (*[]models.Chirp, *models.ResponseErr)
+	// GetReplies lists the direct replies to parentID, oldest first.
+	GetReplies(ctx context.Context, parentID string, after *pagination.Cursor, limit int) (*[]models.Chirp, *models.ResponseErr)
+	// GetDescendants returns every reply below rootIDs down to maxDepth
+	// levels, oldest first. Direct replies to the roots are depth 1.
+	GetDescendants(ctx context.Context, rootIDs []string, maxDepth int) (*[]models.Chirp, *models.ResponseErr)
+	// GetAncestors returns the chain of parents of chirpID, root first.
+	GetAncestors(ctx context.Context, chirpID string) (*[]models.Chirp, *models.ResponseErr)
+	// UpdateChirp replaces the body of a live chirp, keeping the previous
+	// body as a revision.
+	UpdateChirp(ctx context.Context, chirpID, body string) (*models.Chirp, *models.ResponseErr)
+	// GetRevisions lists the previous bodies of chirpID, oldest first.
+	GetRevisions(ctx context.Context, chirpID string) (*[]models.ChirpRevision, *models.ResponseErr)
+	// HideChirp takes a chirp out of every listing on a moderator's
+	// decision. GetChirpByID and GetByIDs skip hidden chirps, threads show
+	// them as tombstones.
+	HideChirp(ctx context.Context, chirpID string) *models.ResponseErr
+	// DeleteChirp removes a chirp and its plain rechirps. Any of them that
+	// has replies turns into a tombstone instead. Either way revisions are
+	// dropped.
+	// Deleting a tombstone reports not found.
+	DeleteChirp(ctx context.Context, chirpID string) *models.ResponseErr
+}
+
+// UsersStore persists user accounts. Implemented by UsersRepository (Postgres)
+// and MemoryUsersRepository.
+type UsersStore interface {
+	CreateUser(ctx context.Context, email, password string, profile models.Profile) (*models.User, *models.ResponseErr)
+	ResetTable(ctx context.Context) *models.ResponseErr
+	GetByID(ctx context.Context, userID string) (*models.User, *models.ResponseErr)
+	GetByEmail(ctx context.Context, email string) (*models.User, *models.ResponseErr)
+	// GetByUsername matches username case-insensitively.
+	GetByUsername(ctx context.Context, username string) (*models.User, *models.ResponseErr)
+	// UpdateAccount clears the email verification when the email changes.
+	UpdateAccount(ctx context.Context, userID, email, password string, profile models.Profile) (*models.User, *models.ResponseErr)
+	UpdatePassword(ctx context.Context, userID, password string) *models.ResponseErr
+	UpgradeToRed(ctx context.Context, userID string) *models.ResponseErr
+	MarkEmailVerified(ctx context.Context, userID string) *models.ResponseErr
+	// SuspendUser marks the account suspended. Suspending twice keeps the
+	// first suspension time.
+	SuspendUser(ctx context.Context, userID string) *models.ResponseErr
+	// GrantRole adds role to the user's roles. Granting a role the user
+	// already has changes nothing.
+	GrantRole(ctx context.Context, userID, role string) (*models.User, *models.ResponseErr)
+	RevokeRole(ctx context.Context, userID, role string) (*models.User, *models.ResponseErr)
+}
+
+// RefreshTokenStore persists refresh tokens. Implemented by
+// RefreshTokenRepository (Postgres) and MemoryRefreshTokenRepository.
+type RefreshTokenStore interface {
+	// SaveRefreshToken stores the first token of a new family, starting the
+	// session of a login.
+	SaveRefreshToken(ctx context.Context, token, userID string, expirationDate time.Time, client models.ClientInfo) (*models.RefreshToken, *models.ResponseErr)
+	// RotateRefreshToken consumes oldToken and stores newToken in its family.
+	// Presenting a token that was already rotated revokes the whole family.
+	RotateRefreshToken(ctx context.Context, oldToken, newToken string, expirationDate time.Time, client models.ClientInfo) (*models.RefreshToken, *models.ResponseErr)
+	GetToken(ctx context.Context, token string) (*models.RefreshToken, *models.ResponseErr)
+	// RevokeToken ends the session token belongs to, revoking every token of
+	// its family. A token that was already rotated still ends the session.
+	RevokeToken(ctx context.Context, token string) *models.ResponseErr
+	// RevokeAllForUser revokes every live refresh token of the user and
+	// returns the ids of the sessions it ended.
+	RevokeAllForUser(ctx context.Context, userID string) ([]uuid.UUID, *models.ResponseErr)
+	// ListSessions returns the user's live sessions, most recently used
+	// first.
+	ListSessions(ctx context.Context, userID string) ([]models.Session, *models.ResponseErr)
+	// RevokeSession revokes one session of the user. It fails with 404 when
+	// the user has no such live session.
+	RevokeSession(ctx context.Context, userID, sessionID string) *models.ResponseErr
+	// RevokeOtherSessions revokes every session of the user but
+	// keepSessionID and returns the ids of the sessions it ended.
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) ([]uuid.UUID, *models.ResponseErr)
+}
+
+// UserTokensStore persists single-use tokens sent by email, such as password
+// reset links, by the hash of the token. Implemented by UserTokensRepository
+// (Postgres) and MemoryUserTokensRepository.
+type UserTokensStore interface {
+	CreateToken(ctx context.Context, tokenHash, userID, purpose string, expiresAt time.Time) *models.ResponseErr
+	// ConsumeToken marks an unused, unexpired token as used and returns it.
+	// Unknown, used and expired tokens get the same 400 error.
+	ConsumeToken(ctx context.Context, tokenHash, purpose string) (*models.UserToken, *models.ResponseErr)
+	// LookupToken returns an unused, unexpired token without using it up,
+	// with the same errors as ConsumeToken.
+	LookupToken(ctx context.Context, tokenHash, purpose string) (*models.UserToken, *models.ResponseErr)
+	// DeleteTokens removes the user's tokens for purpose, used or not.
+	DeleteTokens(ctx context.Context, userID, purpose string) *models.ResponseErr
+}
+
+// IdentitiesStore persists the links between users and their accounts at
+// OpenID Connect providers. Implemented by IdentitiesRepository (Postgres)
+// and MemoryIdentitiesRepository.
+type IdentitiesStore interface {
+	GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, *models.ResponseErr)
+	// LinkIdentity fails with 409 when the provider account is already
+	// linked.
+	LinkIdentity(ctx context.Context, provider, subject, userID, email string) (*models.Identity, *models.ResponseErr)
+}
+
+// SigningKeysStore persists the keys tokens are signed with. Implemented by
+// SigningKeysRepository (Postgres) and MemorySigningKeysRepository.
+type SigningKeysStore interface {
+	ListSigningKeys(ctx context.Context) ([]models.SigningKey, *models.ResponseErr)
+	CreateSigningKey(ctx context.Context, key models.SigningKey) *models.ResponseErr
+	// RetireSigningKeys retires the keys created before createdBefore that
+	// are not retired yet. Going by age rather than id keeps the newest key
+	// signing when two instances rotate at once.
+	RetireSigningKeys(ctx context.Context, createdBefore, retiredAt time.Time) *models.ResponseErr
+	// DeleteSigningKeys removes keys retired before the given time.
+	DeleteSigningKeys(ctx context.Context, retiredBefore time.Time) *models.ResponseErr
+}
+
+// DeniedTokensStore persists revoked access tokens so every instance learns
+// about them. Implemented by DeniedTokensRepository (Postgres) and
+// MemoryDeniedTokensRepository.
+type DeniedTokensStore interface {
+	// DenyTokens denies ids until expiresAt. Denying an id again keeps the
+	// later expiry.
+	DenyTokens(ctx context.Context, ids []string, expiresAt time.Time) *models.ResponseErr
+	// ListDeniedTokens returns the unexpired entries created at or after
+	// since.
+	ListDeniedTokens(ctx context.Context, since time.Time) ([]models.DeniedToken, *models.ResponseErr)
+	DeleteExpiredDeniedTokens(ctx context.Context) *models.ResponseErr
+}
+
+// LoginAttemptsStore persists the audit trail of failed logins and the
+// failure counters logins are throttled by. Implemented by
+// LoginAttemptsRepository (Postgres) and MemoryLoginAttemptsRepository.
+type LoginAttemptsStore interface {
+	RecordLoginAttempt(ctx context.Context, attempt models.LoginAttempt) *models.ResponseErr
+	// ListLoginAttempts returns the attempts matching filter, newest first,
+	// starting after the cursor when one is given.
+	ListLoginAttempts(ctx context.Context, filter models.LoginAttemptFilter, after *pagination.Cursor, limit int) ([]models.LoginAttempt, *models.ResponseErr)
+	// GetLoginThrottles returns the counters of those keys that have one.
+	GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, *models.ResponseErr)
+	// AddLoginFailure counts a failure at the given time against key. A
+	// count whose last failure is older than resetAfter starts over.
+	AddLoginFailure(ctx context.Context, key string, at time.Time, resetAfter time.Duration) (*models.LoginThrottle, *models.ResponseErr)
+	ClearLoginThrottle(ctx context.Context, key string) *models.ResponseErr
+}
+
+// TwoFactorStore persists TOTP enrollments and recovery codes. Implemented
+// by TwoFactorRepository (Postgres) and MemoryTwoFactorRepository.
+type TwoFactorStore interface {
+	GetTOTP(ctx context.Context, userID string) (*models.TOTP, *models.ResponseErr)
+	// SaveTOTPSecret starts or restarts an enrollment. It fails with 409
+	// once 2FA is enabled.
+	SaveTOTPSecret(ctx context.Context, userID, secret string) *models.ResponseErr
+	// EnableTOTP confirms the enrollment at step and replaces the user's
+	// recovery codes.
+	EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) *models.ResponseErr
+	DisableTOTP(ctx context.Context, userID string) *models.ResponseErr
+	// UseTOTPStep records a code accepted at step. Steps at or before the
+	// last recorded one are refused so codes cannot be replayed.
+	UseTOTPStep(ctx context.Context, userID string, step int64) *models.ResponseErr
+	// UseRecoveryCode marks an unused recovery code as used.
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) *models.ResponseErr
+}
+
+// FollowsStore persists the follow graph. Implemented by FollowsRepository
+// (Postgres) and MemoryFollowsRepository.
+type FollowsStore interface {
+	// Follow is idempotent, following someone twice is not an error.
+	Follow(ctx context.Context, followerID, followeeID string) *models.ResponseErr
+	Unfollow(ctx context.Context, followerID, followeeID string) *models.ResponseErr
+	// GetFollowers and GetFollowing list newest follows first. The cursor ID
+	// is the id of the listed user, not of userID.
+	GetFollowers(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Follow, *models.ResponseErr)
+	GetFollowing(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Follow, *models.ResponseErr)
+}
+
+// LikesStore persists likes. Implemented by LikesRepository (Postgres) and
+// MemoryLikesRepository.
+type LikesStore interface {
+	// Like is idempotent, a user can like a chirp only once.
+	Like(ctx context.Context, userID, chirpID string) *models.ResponseErr
+	Unlike(ctx context.Context, userID, chirpID string) *models.ResponseErr
+	// CountLikes returns the like count per chirp. Chirps without likes are
+	// missing from the map.
+	CountLikes(ctx context.Context, chirpIDs []string) (map[uuid.UUID]int, *models.ResponseErr)
+	// GetLikedChirpIDs reports which of chirpIDs userID has liked.
+	GetLikedChirpIDs(ctx context.Context, userID string, chirpIDs []string) (map[uuid.UUID]bool, *models.ResponseErr)
+	// GetLikesByUser lists likes on live chirps, newest first, paging on
+	// (created_at, chirp_id).
+	GetLikesByUser(ctx context.Context, userID string, after *pagination.Cursor, limit int) (*[]models.Like, *models.ResponseErr)
+}
+
+// TagsStore persists the hashtags and mentions extracted from chirps.
+// Implemented by TagsRepository (Postgres) and MemoryTagsRepository.
+type TagsStore interface {
+	// SaveHashtags links chirpID to tags, which must already be normalized.
+	// createdAt should be the chirp's creation time.
+	SaveHashtags(ctx context.Context, chirpID string, tags []string, createdAt time.Time) *models.ResponseErr
+	SaveMentions(ctx context.Context, chirpID string, userIDs []string, createdAt time.Time) *models.ResponseErr
+	// DeleteEntities unlinks every hashtag and mention from chirpID, so they
+	// can be saved again after an edit.
+	DeleteEntities(ctx context.Context, chirpID string) *models.ResponseErr
+	// GetTrendingHashtags counts tag uses on live chirps created since the
+	// given time, most used first.
+	GetTrendingHashtags(ctx context.Context, since time.Time, limit int) (*[]models.TrendingHashtag, *models.ResponseErr)
+}
+
+// ReportsStore persists user reports and the moderation decisions taken on
+// them. Implemented by ReportsRepository (Postgres) and
+// MemoryReportsRepository.
+type ReportsStore interface {
+	// CreateReport files an open report. targetUserID is the reported user
+	// or the author of the reported chirp. A reporter can only have one
+	// unresolved report per target.
+	CreateReport(ctx context.Context, reporterID, targetType, targetID, targetUserID, reason, details string) (*models.Report, *models.ResponseErr)
+	GetReport(ctx context.Context, reportID string) (*models.Report, *models.ResponseErr)
+	// ListReports lists reports oldest first, only those with status unless
+	// it is empty.
+	ListReports(ctx context.Context, status string, after *pagination.Cursor, limit int) (*[]models.Report, *models.ResponseErr)
+	// ClaimReport assigns an open report to moderatorID. Claiming a report
+	// the moderator already holds changes nothing.
+	ClaimReport(ctx context.Context, reportID, moderatorID string) (*models.Report, *models.ResponseErr)
+	// ResolveReport closes a report that is open or claimed by moderatorID
+	// and, in the same transaction, hides the reported chirp or suspends the
+	// reported user when the resolution asks for it.
+	ResolveReport(ctx context.Context, reportID, moderatorID, resolution, note string) (*models.Report, *models.ResponseErr)
+	// GetDecisions lists the decisions taken on a report, oldest first.
+	// Claims and resolutions are both recorded.
+	GetDecisions(ctx context.Context, reportID string) (*[]models.Decision, *models.ResponseErr)
+}
+
+var (
+	_ ChirpsStore        = (*ChirpsRepository)(nil)
+	_ UsersStore         = (*UsersRepository)(nil)
+	_ RefreshTokenStore  = (*RefreshTokenRepository)(nil)
+	_ FollowsStore       = (*FollowsRepository)(nil)
+	_ LikesStore         = (*LikesRepository)(nil)
+	_ TagsStore          = (*TagsRepository)(nil)
+	_ ReportsStore       = (*ReportsRepository)(nil)
+	_ UserTokensStore    = (*UserTokensRepository)(nil)
+	_ TwoFactorStore     = (*TwoFactorRepository)(nil)
+	_ IdentitiesStore    = (*IdentitiesRepository)(nil)
+	_ SigningKeysStore   = (*SigningKeysRepository)(nil)
+	_ DeniedTokensStore  = (*DeniedTokensRepository)(nil)
+	_ LoginAttemptsStore = (*LoginAttemptsRepository)(nil)
+
+	_ ChirpsStore        = (*MemoryChirpsRepository)(nil)
+	_ UsersStore         = (*MemoryUsersRepository)(nil)
+	_ RefreshTokenStore  = (*MemoryRefreshTokenRepository)(nil)
+	_ FollowsStore       = (*MemoryFollowsRepository)(nil)
+	_ LikesStore         = (*MemoryLikesRepository)(nil)
+	_ TagsStore          = (*MemoryTagsRepository)(nil)
+	_ ReportsStore       = (*MemoryReportsRepository)(nil)
+	_ UserTokensStore    = (*MemoryUserTokensRepository)(nil)
+	_ TwoFactorStore     = (*MemoryTwoFactorRepository)(nil)
+	_ IdentitiesStore    = (*MemoryIdentitiesRepository)(nil)
+	_ SigningKeysStore   = (*MemorySigningKeysRepository)(nil)
+	_ DeniedTokensStore  = (*MemoryDeniedTokensRepository)(nil)
+	_ LoginAttemptsStore = (*MemoryLoginAttemptsRepository)(nil)
+)
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type SigningKeysRepository struct {
+	db *sql.DB
+}
+
+func NewSigningKeysRepository(db *sql.DB) SigningKeysRepository {
+	return SigningKeysRepository{
+		db: db,
+	}
+}
+
+func (r *SigningKeysRepository) ListSigningKeys(ctx context.Context) ([]models.SigningKey, *models.ResponseErr) {
+	query := `
+		SELECT id, algorithm, private_key, created_at, retired_at
+		FROM signing_keys
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	keys := []models.SigningKey{}
+	for rows.Next() {
+		var key models.SigningKey
+		var retiredAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Algorithm, &key.Private, &key.CreatedAt, &retiredAt); err != nil {
+			return nil, dbError(err)
+		}
+		if retiredAt.Valid {
+			key.RetiredAt = &retiredAt.Time
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return keys, nil
+}
+
+func (r *SigningKeysRepository) CreateSigningKey(ctx context.Context, key models.SigningKey) *models.ResponseErr {
+	query := `
+		INSERT INTO signing_keys (id, algorithm, private_key, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := r.db.ExecContext(ctx, query, key.ID, key.Algorithm, key.Private, key.CreatedAt.UTC())
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *SigningKeysRepository) RetireSigningKeys(ctx context.Context, createdBefore, retiredAt time.Time) *models.ResponseErr {
+	query := `
+		UPDATE signing_keys
+		SET retired_at = $2
+		WHERE created_at < $1 AND retired_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, createdBefore.UTC(), retiredAt.UTC())
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *SigningKeysRepository) DeleteSigningKeys(ctx context.Context, retiredBefore time.Time) *models.ResponseErr {
+	query := `
+		DELETE FROM signing_keys
+		WHERE retired_at < $1
+	`
+	_, err := r.db.ExecContext(ctx, query, retiredBefore.UTC())
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+	"github.com/lib/pq"
+)
+
+type TagsRepository struct {
+	db *sql.DB
+}
+
+func NewTagsRepository(db *sql.DB) TagsRepository {
+	return TagsRepository{
+		db: db,
+	}
+}
+
+func (r *TagsRepository) SaveHashtags(ctx context.Context, chirpID string, tags []string, createdAt time.Time) *models.ResponseErr {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	query := `
+		WITH new_tags AS (
+			INSERT INTO hashtags (id, tag)
+			SELECT gen_random_uuid(), tag FROM unnest($2::text[]) AS tag
+			ON CONFLICT (tag) DO NOTHING
+			RETURNING id, tag
+		), all_tags AS (
+			SELECT id FROM new_tags
+			UNION
+			SELECT id FROM hashtags WHERE tag = ANY($2)
+		)
+		INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+		SELECT $1, id, $3 FROM all_tags
+		ON CONFLICT DO NOTHING;
+	`
+	_, err := r.db.ExecContext(ctx, query, chirpID, pq.Array(tags), createdAt)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *TagsRepository) SaveMentions(ctx context.Context, chirpID string, userIDs []string, createdAt time.Time) *models.ResponseErr {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO mentions (chirp_id, user_id, created_at)
+		SELECT $1, user_id, $3 FROM unnest($2::uuid[]) AS user_id
+		ON CONFLICT DO NOTHING;
+	`
+	_, err := r.db.ExecContext(ctx, query, chirpID, pq.Array(userIDs), createdAt)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *TagsRepository) DeleteEntities(ctx context.Context, chirpID string) *models.ResponseErr {
+	query := `
+		WITH dropped AS (
+			DELETE FROM chirp_hashtags WHERE chirp_id = $1
+		)
+		DELETE FROM mentions WHERE chirp_id = $1;
+	`
+	_, err := r.db.ExecContext(ctx, query, chirpID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *TagsRepository) GetTrendingHashtags(ctx context.Context, since time.Time, limit int) (*[]models.TrendingHashtag, *models.ResponseErr) {
+	query := `
+		SELECT hashtags.tag, count(*) AS uses
+		FROM chirp_hashtags
+		JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+		JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+		WHERE chirp_hashtags.created_at >= $1 AND chirps.deleted_at IS NULL AND chirps.hidden_at IS NULL
+		GROUP BY hashtags.tag
+		ORDER BY uses DESC, hashtags.tag ASC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var trending []models.TrendingHashtag
+	for rows.Next() {
+		var hashtag models.TrendingHashtag
+		if err := rows.Scan(&hashtag.Tag, &hashtag.Count); err != nil {
+			return nil, dbError(err)
+		}
+		trending = append(trending, hashtag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &trending, nil
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) TwoFactorRepository {
+	return TwoFactorRepository{
+		db: db,
+	}
+}
+
+func (r *TwoFactorRepository) GetTOTP(ctx context.Context, userID string) (*models.TOTP, *models.ResponseErr) {
+	query := `
+		SELECT user_id, secret, created_at, enabled_at, last_step
+		FROM user_totp
+		WHERE user_id = $1
+	`
+	row := r.db.QueryRowContext(ctx, query, userID)
+
+	var totp models.TOTP
+	var enabledAt sql.NullTime
+	if err := row.Scan(&totp.UserID, &totp.Secret, &totp.CreatedAt, &enabledAt, &totp.LastStep); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errTOTPNotFound()
+		}
+		return nil, dbError(err)
+	}
+	if enabledAt.Valid {
+		totp.EnabledAt = &enabledAt.Time
+	}
+
+	return &totp, nil
+}
+
+func (r *TwoFactorRepository) SaveTOTPSecret(ctx context.Context, userID, secret string) *models.ResponseErr {
+	query := `
+		INSERT INTO user_totp (user_id, secret, created_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_step = 0
+		WHERE user_totp.enabled_at IS NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return dbError(err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
+		return errTOTPEnabled()
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) EnableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) *models.ResponseErr {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbError(err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_totp
+		SET enabled_at = now(), last_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL
+	`, userID, step)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected == 0 {
+		return errTOTPEnabled()
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return dbError(err)
+	}
+	for _, codeHash := range recoveryCodeHashes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO recovery_codes (user_id, code_hash, created_at)
+			VALUES ($1, $2, now())
+		`, userID, codeHash)
+		if err != nil {
+			return dbError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) DisableTOTP(ctx context.Context, userID string) *models.ResponseErr {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbError(err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_totp WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return dbError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) UseTOTPStep(ctx context.Context, userID string, step int64) *models.ResponseErr {
+	query := `
+		UPDATE user_totp
+		SET last_step = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_step < $2
+	`
+	return r.useOnce(ctx, query, userID, step)
+}
+
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) *models.ResponseErr {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	return r.useOnce(ctx, query, userID, codeHash, time.Now().UTC())
+}
+
+// useOnce runs an update that only matches an unused code.
+func (r *TwoFactorRepository) useOnce(ctx context.Context, query string, args ...any) *models.ResponseErr {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return dbError(err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
+		return errInvalidSecondFactor()
+	}
+
+	return nil
+}
+
+func errTOTPNotFound() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Two-factor authentication is not set up",
+		StatusCode: http.StatusNotFound,
+	}
+}
+
+func errTOTPEnabled() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Two-factor authentication is already enabled",
+		StatusCode: http.StatusConflict,
+	}
+}
+
+func errInvalidSecondFactor() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Invalid code",
+		StatusCode: http.StatusUnauthorized,
+		Code:       models.CodeInvalidMFACode,
+	}
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/karaMuha/go-chirpy/models"
+)
+
+type UserTokensRepository struct {
+	db *sql.DB
+}
+
+func NewUserTokensRepository(db *sql.DB) UserTokensRepository {
+	return UserTokensRepository{
+		db: db,
+	}
+}
+
+func (r *UserTokensRepository) CreateToken(ctx context.Context, tokenHash, userID, purpose string, expiresAt time.Time) *models.ResponseErr {
+	query := `
+		INSERT INTO user_tokens (token_hash, user_id, purpose, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.db.ExecContext(ctx, query, tokenHash, userID, purpose, time.Now().UTC(), expiresAt.UTC())
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *UserTokensRepository) ConsumeToken(ctx context.Context, tokenHash, purpose string) (*models.UserToken, *models.ResponseErr) {
+	now := time.Now().UTC()
+	query := `
+		UPDATE user_tokens
+		SET used_at = $3
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING token_hash, user_id, purpose, created_at, expires_at
+	`
+	row := r.db.QueryRowContext(ctx, query, tokenHash, purpose, now)
+
+	token := models.UserToken{UsedAt: &now}
+	if err := row.Scan(&token.TokenHash, &token.UserID, &token.Purpose, &token.CreatedAt, &token.ExpiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errInvalidUserToken()
+		}
+		return nil, dbError(err)
+	}
+
+	return &token, nil
+}
+
+func (r *UserTokensRepository) LookupToken(ctx context.Context, tokenHash, purpose string) (*models.UserToken, *models.ResponseErr) {
+	query := `
+		SELECT token_hash, user_id, purpose, created_at, expires_at
+		FROM user_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+	`
+	row := r.db.QueryRowContext(ctx, query, tokenHash, purpose, time.Now().UTC())
+
+	var token models.UserToken
+	if err := row.Scan(&token.TokenHash, &token.UserID, &token.Purpose, &token.CreatedAt, &token.ExpiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errInvalidUserToken()
+		}
+		return nil, dbError(err)
+	}
+
+	return &token, nil
+}
+
+func (r *UserTokensRepository) DeleteTokens(ctx context.Context, userID, purpose string) *models.ResponseErr {
+	query := `
+		DELETE FROM user_tokens
+		WHERE user_id = $1 AND purpose = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, purpose)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// errInvalidUserToken does not say whether the token was unknown, used or
+// expired.
+func errInvalidUserToken() *models.ResponseErr {
+	return &models.ResponseErr{
+		Error:      "Invalid or expired token",
+		StatusCode: http.StatusBadRequest,
+		Code:       models.CodeInvalidUserToken,
+	}
+}
@@ -8,8 +8,59 @@ import (
 	"strings"
 
 	"github.com/karaMuha/go-chirpy/models"
+	"github.com/lib/pq"
 )
 
+const userColumns = "id, created_at, updated_at, email, hashed_password, is_chirpy_red, username, display_name, bio, avatar_url, roles, suspended_at, email_verified_at"
+
+func scanUser(row rowScanner, user *models.User) error {
+	var suspendedAt, emailVerifiedAt sql.NullTime
+	if err := row.Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Email,
+		&user.Password,
+		&user.IsChirpyRed,
+		&user.Username,
+		&user.DisplayName,
+		&user.Bio,
+		&user.AvatarURL,
+		pq.Array(&user.Roles),
+		&suspendedAt,
+		&emailVerifiedAt,
+	); err != nil {
+		return err
+	}
+
+	if suspendedAt.Valid {
+		user.SuspendedAt = &suspendedAt.Time
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+
+	return nil
+}
+
+// userConflictErr maps a unique violation on users to the field that caused
+// it, or returns nil for any other error.
+func userConflictErr(err error) *models.ResponseErr {
+	if !strings.Contains(err.Error(), "unique constraint") {
+		return nil
+	}
+	if strings.Contains(err.Error(), "username") {
+		return &models.ResponseErr{
+			Error:      "Username already exists",
+			StatusCode: http.StatusConflict,
+		}
+	}
+	return &models.ResponseErr{
+		Error:      "Email already exists",
+		StatusCode: http.StatusConflict,
+	}
+}
+
 type UsersRepository struct {
 	db *sql.DB
 }
@@ -20,35 +71,21 @@ func NewUsersRepository(db *sql.DB) UsersRepository {
 	}
 }
 
-func (r *UsersRepository) CreateUser(ctx context.Context, email, password string) (*models.User, *models.ResponseErr) {
+func (r *UsersRepository) CreateUser(ctx context.Context, email, password string, profile models.Profile) (*models.User, *models.ResponseErr) {
 	if r.db == nil {
 		fmt.Println("UserRepo DB is nil")
 	}
 	query := `
-		INSERT INTO users (id, created_at, updated_at, email, hashed_password)
-		VALUES (gen_random_uuid(), now(), now(), $1, $2)
-		RETURNING *;
-	`
-	row := r.db.QueryRowContext(ctx, query, email, password)
+		INSERT INTO users (id, created_at, updated_at, email, hashed_password, username, display_name, bio, avatar_url)
+		VALUES (gen_random_uuid(), now(), now(), $1, $2, $3, $4, $5, $6)
+		RETURNING ` + userColumns
+	row := r.db.QueryRowContext(ctx, query, email, password, profile.Username, profile.DisplayName, profile.Bio, profile.AvatarURL)
 	var user models.User
-	if err := row.Scan(
-		&user.ID,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.Email,
-		&user.Password,
-		&user.IsChirpyRed,
-	); err != nil {
-		if strings.Contains(err.Error(), "unique constraint") {
-			return nil, &models.ResponseErr{
-				Error:      "Email already exists",
-				StatusCode: http.StatusConflict,
-			}
-		}
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+	if err := scanUser(row, &user); err != nil {
+		if respErr := userConflictErr(err); respErr != nil {
+			return nil, respErr
 		}
+		return nil, dbError(err)
 	}
 
 	return &user, nil
@@ -60,10 +97,7 @@ func (r *UsersRepository) ResetTable(ctx context.Context) *models.ResponseErr {
 	`
 	_, err := r.db.ExecContext(ctx, query)
 	if err != nil {
-		return &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
-		}
+		return dbError(err)
 	}
 
 	return nil
@@ -71,110 +105,168 @@ func (r *UsersRepository) ResetTable(ctx context.Context) *models.ResponseErr {
 
 func (r *UsersRepository) GetByID(ctx context.Context, userID string) (*models.User, *models.ResponseErr) {
 	query := `
-		SELECT *
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1
 	`
-	row := r.db.QueryRowContext(ctx, query, userID)
+	return r.getOne(ctx, query, userID)
+}
+
+func (r *UsersRepository) GetByEmail(ctx context.Context, email string) (*models.User, *models.ResponseErr) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE email = $1
+	`
+	return r.getOne(ctx, query, email)
+}
+
+func (r *UsersRepository) GetByUsername(ctx context.Context, username string) (*models.User, *models.ResponseErr) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE lower(username) = lower($1)
+	`
+	return r.getOne(ctx, query, username)
+}
+
+func (r *UsersRepository) getOne(ctx context.Context, query string, arg any) (*models.User, *models.ResponseErr) {
+	row := r.db.QueryRowContext(ctx, query, arg)
+
 	var user models.User
-	if err := row.Scan(
-		&user.ID,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.Email,
-		&user.Password,
-		&user.IsChirpyRed,
-	); err != nil {
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+	if err := scanUser(row, &user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &models.ResponseErr{
+				Error:      "User not found",
+				StatusCode: http.StatusNotFound,
+			}
 		}
+		return nil, dbError(err)
 	}
 
 	return &user, nil
 }
 
-func (r *UsersRepository) GetByEmail(ctx context.Context, email string) (*models.User, *models.ResponseErr) {
+func (r *UsersRepository) UpdateAccount(ctx context.Context, userID, email, password string, profile models.Profile) (*models.User, *models.ResponseErr) {
 	query := `
-		SELECT *
-		FROM users
-		WHERE email = $1
-	`
-	row := r.db.QueryRowContext(ctx, query, email)
+		UPDATE users
+		SET email = $1, hashed_password = $2, username = $3, display_name = $4, bio = $5, avatar_url = $6, updated_at = now(),
+			email_verified_at = CASE WHEN email = $1 THEN email_verified_at END
+		WHERE id = $7
+		RETURNING ` + userColumns
+	row := r.db.QueryRowContext(ctx, query, email, password, profile.Username, profile.DisplayName, profile.Bio, profile.AvatarURL, userID)
 
 	var user models.User
-	if err := row.Scan(
-		&user.ID,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.Email,
-		&user.Password,
-		&user.IsChirpyRed,
-	); err != nil {
+	if err := scanUser(row, &user); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &models.ResponseErr{
 				Error:      "User not found",
 				StatusCode: http.StatusNotFound,
 			}
 		}
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+		if respErr := userConflictErr(err); respErr != nil {
+			return nil, respErr
 		}
+		return nil, dbError(err)
 	}
 
 	return &user, nil
 }
 
-func (r *UsersRepository) UpdateAccount(ctx context.Context, userID, email, password string) (*models.User, *models.ResponseErr) {
+func (r *UsersRepository) UpdatePassword(ctx context.Context, userID, password string) *models.ResponseErr {
 	query := `
 		UPDATE users
-		SET email = $1, hashed_password = $2, updated_at = now()
-		WHERE id = $3
-		RETURNING *;
+		SET hashed_password = $1, updated_at = now()
+		WHERE id = $2
 	`
-	row := r.db.QueryRowContext(ctx, query, email, password, userID)
+	res, err := r.db.ExecContext(ctx, query, password, userID)
+	if err != nil {
+		return dbError(err)
+	}
 
-	var user models.User
-	if err := row.Scan(
-		&user.ID,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.Email,
-		&user.Password,
-		&user.IsChirpyRed,
-	); err != nil {
-		return nil, &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
+		return &models.ResponseErr{
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
 		}
 	}
 
-	return &user, nil
+	return nil
 }
 
-func (r *UsersRepository) UpgradeToRed(ctx context.Context, userID string) *models.ResponseErr {
+func (r *UsersRepository) MarkEmailVerified(ctx context.Context, userID string) *models.ResponseErr {
 	query := `
 		UPDATE users
-		SET is_chirpy_red = true
+		SET email_verified_at = COALESCE(email_verified_at, now())
 		WHERE id = $1
 	`
 	res, err := r.db.ExecContext(ctx, query, userID)
 	if err != nil {
+		return dbError(err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
 		return &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
 		}
 	}
 
+	return nil
+}
+
+func (r *UsersRepository) UpgradeToRed(ctx context.Context, userID string) *models.ResponseErr {
+	query := `
+		UPDATE users
+		SET is_chirpy_red = true
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return dbError(err)
+	}
+
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
+		return dbError(err)
+	}
+
+	if rowsAffected == 0 {
 		return &models.ResponseErr{
-			Error:      err.Error(),
-			StatusCode: http.StatusInternalServerError,
+			Error:      "User not found",
+			StatusCode: http.StatusNotFound,
 		}
 	}
 
+	return nil
+}
+
+func (r *UsersRepository) SuspendUser(ctx context.Context, userID string) *models.ResponseErr {
+	query := `
+		UPDATE users
+		SET suspended_at = COALESCE(suspended_at, now())
+		WHERE id = $1
+	`
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return dbError(err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
 	if rowsAffected == 0 {
 		return &models.ResponseErr{
 			Error:      "User not found",
@@ -184,3 +276,38 @@ func (r *UsersRepository) UpgradeToRed(ctx context.Context, userID string) *mode
 
 	return nil
 }
+
+func (r *UsersRepository) GrantRole(ctx context.Context, userID, role string) (*models.User, *models.ResponseErr) {
+	query := `
+		UPDATE users
+		SET roles = CASE WHEN $1 = ANY (roles) THEN roles ELSE array_append(roles, $1) END
+		WHERE id = $2
+		RETURNING ` + userColumns
+	return r.updateRoles(ctx, query, role, userID)
+}
+
+func (r *UsersRepository) RevokeRole(ctx context.Context, userID, role string) (*models.User, *models.ResponseErr) {
+	query := `
+		UPDATE users
+		SET roles = array_remove(roles, $1)
+		WHERE id = $2
+		RETURNING ` + userColumns
+	return r.updateRoles(ctx, query, role, userID)
+}
+
+func (r *UsersRepository) updateRoles(ctx context.Context, query, role, userID string) (*models.User, *models.ResponseErr) {
+	row := r.db.QueryRowContext(ctx, query, role, userID)
+
+	var user models.User
+	if err := scanUser(row, &user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &models.ResponseErr{
+				Error:      "User not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		return nil, dbError(err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN reply_to_id UUID REFERENCES chirps ON DELETE SET NULL;
+ALTER TABLE chirps ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS chirps_reply_to_id_idx ON chirps (reply_to_id, created_at, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_reply_to_id_idx;
+ALTER TABLE chirps DROP COLUMN deleted_at;
+ALTER TABLE chirps DROP COLUMN reply_to_id;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS likes (
+  user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+  chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX IF NOT EXISTS likes_chirp_id_idx ON likes (chirp_id);
+CREATE INDEX IF NOT EXISTS likes_user_id_created_at_idx ON likes (user_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE likes;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- No foreign key on purpose: a quote keeps pointing at its original after
+-- the original is deleted so it can be shown as unavailable.
+ALTER TABLE chirps ADD COLUMN repost_of_id UUID;
+CREATE INDEX IF NOT EXISTS chirps_repost_of_id_idx ON chirps (repost_of_id);
+-- A user can plainly rechirp a chirp only once, quotes are not limited.
+CREATE UNIQUE INDEX IF NOT EXISTS chirps_unique_rechirp_idx ON chirps (user_id, repost_of_id)
+  WHERE repost_of_id IS NOT NULL AND body = '' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_unique_rechirp_idx;
+DROP INDEX IF EXISTS chirps_repost_of_id_idx;
+ALTER TABLE chirps DROP COLUMN repost_of_id;
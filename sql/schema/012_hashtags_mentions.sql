@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS hashtags (
+  id UUID PRIMARY KEY,
+  tag TEXT UNIQUE NOT NULL
+);
+
+-- created_at copies the chirp's so listings can page without a join.
+CREATE TABLE IF NOT EXISTS chirp_hashtags (
+  chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+  hashtag_id UUID NOT NULL REFERENCES hashtags ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (chirp_id, hashtag_id)
+);
+CREATE INDEX IF NOT EXISTS chirp_hashtags_hashtag_id_idx ON chirp_hashtags (hashtag_id, created_at, chirp_id);
+CREATE INDEX IF NOT EXISTS chirp_hashtags_created_at_idx ON chirp_hashtags (created_at);
+
+CREATE TABLE IF NOT EXISTS mentions (
+  chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (chirp_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS mentions_user_id_idx ON mentions (user_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE mentions;
+DROP TABLE chirp_hashtags;
+DROP TABLE hashtags;
@@ -0,0 +1,35 @@
+-- +goose Up
+ALTER TABLE users
+  ADD COLUMN username TEXT,
+  ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+  ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+  ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- Backfill usernames from the local part of the email. Bases only keep
+-- letters and digits, so the "_n" suffix added to duplicates cannot collide
+-- with another base.
+WITH bases AS (
+  SELECT id, left(regexp_replace(lower(split_part(email, '@', 1)), '[^a-z0-9]', '', 'g'), 24) AS base
+  FROM users
+), candidates AS (
+  SELECT id, CASE WHEN length(base) < 3 THEN 'user' || base ELSE base END AS base
+  FROM bases
+), numbered AS (
+  SELECT id, base, row_number() OVER (PARTITION BY base ORDER BY id) AS n
+  FROM candidates
+)
+UPDATE users
+SET username = CASE WHEN numbered.n = 1 THEN numbered.base ELSE numbered.base || '_' || numbered.n END
+FROM numbered
+WHERE users.id = numbered.id;
+
+ALTER TABLE users ALTER COLUMN username SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_idx ON users (lower(username));
+
+-- +goose Down
+DROP INDEX IF EXISTS users_username_lower_idx;
+ALTER TABLE users
+  DROP COLUMN avatar_url,
+  DROP COLUMN bio,
+  DROP COLUMN display_name,
+  DROP COLUMN username;
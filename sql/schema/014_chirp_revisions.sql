@@ -0,0 +1,14 @@
+-- +goose Up
+-- Each row keeps a body a chirp had before an edit. created_at is when that
+-- body was written, replaced_at when the edit replaced it.
+CREATE TABLE IF NOT EXISTS chirp_revisions (
+  id UUID PRIMARY KEY,
+  chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  replaced_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chirp_revisions_chirp_id_idx ON chirp_revisions (chirp_id, replaced_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
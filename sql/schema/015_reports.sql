@@ -0,0 +1,43 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN hidden_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP;
+
+-- target_id has no foreign key so reports and their decisions outlive the
+-- chirp or user they are about. target_user_id is the reported user or the
+-- author of the reported chirp.
+CREATE TABLE IF NOT EXISTS reports (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  reporter_id UUID REFERENCES users ON DELETE SET NULL,
+  target_type TEXT NOT NULL CHECK (target_type IN ('chirp', 'user')),
+  target_id UUID NOT NULL,
+  target_user_id UUID NOT NULL,
+  reason TEXT NOT NULL,
+  details TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'claimed', 'resolved')),
+  claimed_by UUID REFERENCES users ON DELETE SET NULL,
+  claimed_at TIMESTAMP,
+  resolved_at TIMESTAMP,
+  resolution TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS reports_status_idx ON reports (status, created_at, id);
+CREATE UNIQUE INDEX IF NOT EXISTS reports_unique_unresolved_idx
+  ON reports (reporter_id, target_type, target_id)
+  WHERE status <> 'resolved';
+
+CREATE TABLE IF NOT EXISTS moderation_decisions (
+  id UUID PRIMARY KEY,
+  report_id UUID NOT NULL REFERENCES reports ON DELETE CASCADE,
+  moderator_id UUID REFERENCES users ON DELETE SET NULL,
+  action TEXT NOT NULL,
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS moderation_decisions_report_id_idx ON moderation_decisions (report_id, created_at);
+
+-- +goose Down
+DROP TABLE moderation_decisions;
+DROP TABLE reports;
+ALTER TABLE users DROP COLUMN suspended_at;
+ALTER TABLE chirps DROP COLUMN hidden_at;
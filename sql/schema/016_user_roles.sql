@@ -0,0 +1,9 @@
+-- +goose Up
+-- Every account has the user role. moderator and admin are granted through
+-- the admin API.
+ALTER TABLE users
+  ADD COLUMN roles TEXT[] NOT NULL DEFAULT ARRAY['user']
+  CHECK (roles <@ ARRAY['user', 'moderator', 'admin'] AND 'user' = ANY (roles));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN roles;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Single-use tokens sent by email. token_hash is the SHA-256 of the token so
+-- a leaked table cannot be used to take over accounts.
+CREATE TABLE IF NOT EXISTS user_tokens (
+  token_hash TEXT PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+  purpose TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS user_tokens_user_id_idx ON user_tokens (user_id, purpose);
+
+-- +goose Down
+DROP TABLE user_tokens;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Accounts from before verification existed count as verified, so turning
+-- on REQUIRE_VERIFIED_EMAIL does not lock them out.
+UPDATE users SET email_verified_at = created_at;
+
+-- Emails are looked up with a lower case domain from now on, so existing
+-- addresses are normalized the same way or their owners could no longer
+-- log in. An address that would collide with another account's is left as
+-- it is rather than failing the migration.
+WITH normalized AS (
+  SELECT id, email AS original,
+    substring(email from '^(.*)@') || '@' || lower(substring(email from '@([^@]*)$')) AS email
+  FROM users
+  WHERE email LIKE '%@%'
+)
+UPDATE users
+SET email = normalized.email
+FROM normalized
+WHERE users.id = normalized.id
+  AND normalized.email <> normalized.original
+  AND NOT EXISTS (SELECT 1 FROM users other WHERE other.id <> normalized.id AND other.email = normalized.email)
+  AND NOT EXISTS (SELECT 1 FROM normalized twin WHERE twin.id <> normalized.id AND twin.email = normalized.email);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN email_verified_at;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id UUID PRIMARY KEY REFERENCES users ON DELETE CASCADE,
+  secret TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  enabled_at TIMESTAMP,
+  last_step BIGINT NOT NULL DEFAULT 0
+);
+
+-- Recovery codes are stored as SHA-256 hashes. They carry 80 random bits,
+-- which makes a slow hash unnecessary.
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP,
+  PRIMARY KEY (user_id, code_hash)
+);
+
+-- +goose Down
+DROP TABLE recovery_codes;
+DROP TABLE user_totp;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Refresh tokens rotate on every use. All tokens descending from one login
+-- share a family_id, parent_token is the token a token replaced and
+-- rotated_at marks tokens that were already used. Presenting a rotated
+-- token again means it was copied, so its whole family is revoked.
+ALTER TABLE refresh_tokens
+  ADD COLUMN family_id UUID,
+  ADD COLUMN parent_token TEXT REFERENCES refresh_tokens (token) ON DELETE SET NULL,
+  ADD COLUMN rotated_at TIMESTAMP;
+UPDATE refresh_tokens SET family_id = gen_random_uuid();
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_family_id_idx;
+ALTER TABLE refresh_tokens
+  DROP COLUMN rotated_at,
+  DROP COLUMN parent_token,
+  DROP COLUMN family_id;
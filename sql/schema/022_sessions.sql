@@ -0,0 +1,13 @@
+-- +goose Up
+-- The client each refresh token was issued to, shown when users review
+-- their sessions.
+ALTER TABLE refresh_tokens
+  ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+  ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_id_idx;
+ALTER TABLE refresh_tokens
+  DROP COLUMN ip,
+  DROP COLUMN user_agent;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Keys access tokens are signed with. The newest key without retired_at
+-- signs, retired keys only verify tokens issued before they were rotated
+-- out and are deleted once those tokens have expired.
+CREATE TABLE IF NOT EXISTS signing_keys (
+  id TEXT PRIMARY KEY,
+  algorithm TEXT NOT NULL,
+  private_key BYTEA NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  retired_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE signing_keys;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Access tokens revoked before they expire, by jti or session id. Rows can
+-- be deleted once expires_at has passed since the tokens are dead anyway.
+CREATE TABLE IF NOT EXISTS denied_tokens (
+  id TEXT PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS denied_tokens_created_at_idx ON denied_tokens (created_at);
+
+-- +goose Down
+DROP TABLE denied_tokens;
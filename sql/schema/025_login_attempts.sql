@@ -0,0 +1,27 @@
+-- +goose Up
+-- Audit trail of failed logins. Rows outlive the account they were aimed
+-- at, user_id is only cleared.
+CREATE TABLE IF NOT EXISTS login_attempts (
+  id UUID PRIMARY KEY,
+  email TEXT NOT NULL,
+  user_id UUID REFERENCES users ON DELETE SET NULL,
+  ip TEXT NOT NULL,
+  user_agent TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS login_attempts_created_at_idx ON login_attempts (created_at, id);
+CREATE INDEX IF NOT EXISTS login_attempts_user_id_idx ON login_attempts (user_id, created_at);
+CREATE INDEX IF NOT EXISTS login_attempts_ip_idx ON login_attempts (ip, created_at);
+
+-- Recent failures per account ("email:<address>") and per client address
+-- ("ip:<address>"), which decide how long logins back off.
+CREATE TABLE IF NOT EXISTS login_throttles (
+  key TEXT PRIMARY KEY,
+  failures INTEGER NOT NULL,
+  last_failure_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE login_throttles;
+DROP TABLE login_attempts;
@@ -4,18 +4,26 @@ import (
 	"log"
 	"net/http"
 	"sync/atomic"
+
+	"github.com/karaMuha/go-chirpy/internal/auth"
 )
 
 type AppState struct {
 	fileserverHits atomic.Int32
 	Platform       string
-	Secret         string
 	PolkaKey       string
+	// Keys sign and verify the tokens Chirpy issues.
+	Keys *auth.Keyring
+	// Denylist rejects access tokens revoked before they expire.
+	Denylist *auth.Denylist
+	// AdminEmails are granted the admin role when they log in.
+	AdminEmails []string
 }
 
 func NewAppState(platform string) *AppState {
 	return &AppState{
 		Platform: platform,
+		Denylist: auth.NewDenylist(),
 	}
 }
 